@@ -3,11 +3,16 @@ module github.com/xops-infra/noop
 go 1.19
 
 require (
+	github.com/golang/snappy v0.0.4
+	go.opentelemetry.io/otel/trace v1.16.0
+	go.uber.org/multierr v1.6.0
 	go.uber.org/zap v1.24.0
+	golang.org/x/sys v0.10.0
 	gopkg.in/natefinch/lumberjack.v2 v2.2.1
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
+	go.opentelemetry.io/otel v1.16.0 // indirect
 	go.uber.org/atomic v1.7.0 // indirect
-	go.uber.org/multierr v1.6.0 // indirect
 )
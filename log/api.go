@@ -1,83 +1,182 @@
 package log
 
-import "go.uber.org/zap"
+import (
+	"context"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// defaultLogger 全局函数使用的默认实例，可能在其他 goroutine 打日志时被替换
+var defaultLogger atomic.Pointer[Logger]
+
+// nopLogger 未设置默认实例时 L 返回的日志器，丢弃所有日志
+var nopLogger = &Logger{
+	zapLogger:   zap.NewNop(),
+	config:      New(),
+	stdoutLevel: zap.NewAtomicLevelAt(zapcore.Level(FatalLevel)),
+	fileLevel:   zap.NewAtomicLevelAt(zapcore.Level(FatalLevel)),
+}
+
+// SetDefault 替换全局函数使用的默认实例，被替换的实例不会关闭，传入 nil 时全局函数不再输出
+func SetDefault(logger *Logger) {
+	defaultLogger.Store(logger)
+}
+
+// L 返回当前的默认实例，未设置时返回丢弃所有日志的实例
+func L() *Logger {
+	if logger := defaultLogger.Load(); logger != nil {
+		return logger
+	}
+	return nopLogger
+}
 
 // 向后兼容的全局函数，使用默认实例
 func Trace(msg string, fields ...zap.Field) {
-	if defaultLogger != nil {
-		defaultLogger.zapLogger.Debug(msg, fields...)
+	if logger := defaultLogger.Load(); logger != nil {
+		if ce := logger.zapLogger.Check(zapcore.Level(TraceLevel), msg); ce != nil {
+			ce.Write(fields...)
+		}
 	}
 }
 
 func Debug(msg string, fields ...zap.Field) {
-	if defaultLogger != nil {
-		defaultLogger.zapLogger.Debug(msg, fields...)
+	if logger := defaultLogger.Load(); logger != nil {
+		logger.zapLogger.Debug(msg, fields...)
 	}
 }
 
 func Info(msg string, fields ...zap.Field) {
-	if defaultLogger != nil {
-		defaultLogger.zapLogger.Info(msg, fields...)
+	if logger := defaultLogger.Load(); logger != nil {
+		logger.zapLogger.Info(msg, fields...)
 	}
 }
 
 func Warn(msg string, fields ...zap.Field) {
-	if defaultLogger != nil {
-		defaultLogger.zapLogger.Warn(msg, fields...)
+	if logger := defaultLogger.Load(); logger != nil {
+		logger.zapLogger.Warn(msg, fields...)
 	}
 }
 
 func Error(msg string, fields ...zap.Field) {
-	if defaultLogger != nil {
-		defaultLogger.zapLogger.Error(msg, fields...)
+	if logger := defaultLogger.Load(); logger != nil {
+		logger.zapLogger.Error(msg, fields...)
 	}
 }
 
 func Fatal(msg string, fields ...zap.Field) {
-	if defaultLogger != nil {
-		defaultLogger.zapLogger.Fatal(msg, fields...)
+	if logger := defaultLogger.Load(); logger != nil {
+		logger.zapLogger.Fatal(msg, fields...)
 	}
 }
 
 func Panic(msg string, fields ...zap.Field) {
-	if defaultLogger != nil {
-		defaultLogger.zapLogger.Panic(msg, fields...)
+	if logger := defaultLogger.Load(); logger != nil {
+		logger.zapLogger.Panic(msg, fields...)
+	}
+}
+
+func Tracef(template string, args ...any) {
+	if logger := defaultLogger.Load(); logger != nil {
+		if ce := logger.zapLogger.Check(zapcore.Level(TraceLevel), template); ce != nil {
+			ce.Message = sprintf(template, args)
+			ce.Write()
+		}
 	}
 }
 
 func Debugf(template string, args ...any) {
-	if defaultLogger != nil {
-		defaultLogger.zapLogger.Sugar().Debugf(template, args...)
+	if logger := defaultLogger.Load(); logger != nil {
+		logger.zapLogger.Sugar().Debugf(template, args...)
 	}
 }
 
 func Infof(template string, args ...any) {
-	if defaultLogger != nil {
-		defaultLogger.zapLogger.Sugar().Infof(template, args...)
+	if logger := defaultLogger.Load(); logger != nil {
+		logger.zapLogger.Sugar().Infof(template, args...)
 	}
 }
 
 func Warnf(template string, args ...any) {
-	if defaultLogger != nil {
-		defaultLogger.zapLogger.Sugar().Warnf(template, args...)
+	if logger := defaultLogger.Load(); logger != nil {
+		logger.zapLogger.Sugar().Warnf(template, args...)
 	}
 }
 
 func Errorf(template string, args ...any) {
-	if defaultLogger != nil {
-		defaultLogger.zapLogger.Sugar().Errorf(template, args...)
+	if logger := defaultLogger.Load(); logger != nil {
+		logger.zapLogger.Sugar().Errorf(template, args...)
 	}
 }
 
 func Fatalf(template string, args ...any) {
-	if defaultLogger != nil {
-		defaultLogger.zapLogger.Sugar().Fatalf(template, args...)
+	if logger := defaultLogger.Load(); logger != nil {
+		logger.zapLogger.Sugar().Fatalf(template, args...)
 	}
 }
 
 func Panicf(template string, args ...any) {
-	if defaultLogger != nil {
-		defaultLogger.zapLogger.Sugar().Panicf(template, args...)
+	if logger := defaultLogger.Load(); logger != nil {
+		logger.zapLogger.Sugar().Panicf(template, args...)
+	}
+}
+
+// Shutdown 关闭默认实例，之后的全局函数不再输出，适合在 main 中 defer 调用
+func Shutdown() error {
+	if logger := defaultLogger.Swap(nil); logger != nil {
+		return logger.Close()
+	}
+	return nil
+}
+
+// TraceCtx 等 XxxCtx 函数使用 ctx 中携带的日志器，没有时使用默认实例，并输出从 ctx 中提取的字段
+func TraceCtx(ctx context.Context, msg string, fields ...zap.Field) {
+	logger := FromContext(ctx)
+	if ce := logger.zapLogger.Check(zapcore.Level(TraceLevel), msg); ce != nil {
+		ce.Write(logger.contextFields(ctx, fields)...)
+	}
+}
+
+func DebugCtx(ctx context.Context, msg string, fields ...zap.Field) {
+	logger := FromContext(ctx)
+	if ce := logger.zapLogger.Check(zapcore.DebugLevel, msg); ce != nil {
+		ce.Write(logger.contextFields(ctx, fields)...)
+	}
+}
+
+func InfoCtx(ctx context.Context, msg string, fields ...zap.Field) {
+	logger := FromContext(ctx)
+	if ce := logger.zapLogger.Check(zapcore.InfoLevel, msg); ce != nil {
+		ce.Write(logger.contextFields(ctx, fields)...)
+	}
+}
+
+func WarnCtx(ctx context.Context, msg string, fields ...zap.Field) {
+	logger := FromContext(ctx)
+	if ce := logger.zapLogger.Check(zapcore.WarnLevel, msg); ce != nil {
+		ce.Write(logger.contextFields(ctx, fields)...)
+	}
+}
+
+func ErrorCtx(ctx context.Context, msg string, fields ...zap.Field) {
+	logger := FromContext(ctx)
+	if ce := logger.zapLogger.Check(zapcore.ErrorLevel, msg); ce != nil {
+		ce.Write(logger.contextFields(ctx, fields)...)
+	}
+}
+
+func FatalCtx(ctx context.Context, msg string, fields ...zap.Field) {
+	logger := FromContext(ctx)
+	if ce := logger.zapLogger.Check(zapcore.FatalLevel, msg); ce != nil {
+		ce.Write(logger.contextFields(ctx, fields)...)
+	}
+}
+
+func PanicCtx(ctx context.Context, msg string, fields ...zap.Field) {
+	logger := FromContext(ctx)
+	if ce := logger.zapLogger.Check(zapcore.PanicLevel, msg); ce != nil {
+		ce.Write(logger.contextFields(ctx, fields)...)
 	}
 }
 
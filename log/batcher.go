@@ -0,0 +1,227 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/multierr"
+)
+
+// errBatchBufferFull 缓冲的条目超过 max_buffer_bytes 时丢弃新的日志
+var errBatchBufferFull = errors.New("sink buffer full, entry dropped")
+
+// batchOptions 批量推送的参数，由 URL 的 batch_size、batch_bytes、batch_wait、
+// max_buffer_bytes、max_retries 参数设置
+type batchOptions struct {
+	batchSize  int
+	batchBytes int
+	batchWait  time.Duration
+	maxBuffer  int
+	maxRetries int
+	minBackoff time.Duration
+	maxBackoff time.Duration
+}
+
+// setBatchQuery 将非零的批量参数写入 query，供 WithLoki、WithElasticsearch 生成 URL
+func setBatchQuery(query url.Values, batchSize, batchBytes, maxBuffer, maxRetries int, batchWait time.Duration) {
+	for name, value := range map[string]int{
+		"batch_size":       batchSize,
+		"batch_bytes":      batchBytes,
+		"max_buffer_bytes": maxBuffer,
+		"max_retries":      maxRetries,
+	} {
+		if value != 0 {
+			query.Set(name, strconv.Itoa(value))
+		}
+	}
+	if batchWait != 0 {
+		query.Set("batch_wait", batchWait.String())
+	}
+}
+
+// parseBatchQuery 从 query 中读取批量参数，未出现的参数保持 o 中的默认值
+func (o *batchOptions) parseBatchQuery(query url.Values) error {
+	for name, target := range map[string]*int{
+		"batch_size":       &o.batchSize,
+		"batch_bytes":      &o.batchBytes,
+		"max_buffer_bytes": &o.maxBuffer,
+		"max_retries":      &o.maxRetries,
+	} {
+		if value := query.Get(name); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 || (n == 0 && name != "max_retries") {
+				return fmt.Errorf("invalid %s %q", name, value)
+			}
+			*target = n
+		}
+	}
+	if value := query.Get("batch_wait"); value != "" {
+		wait, err := time.ParseDuration(value)
+		if err != nil || wait <= 0 {
+			return fmt.Errorf("invalid batch_wait %q", value)
+		}
+		o.batchWait = wait
+	}
+	return nil
+}
+
+// batchResult 推送一批条目的结果
+type batchResult[T any] struct {
+	// retry 可以重试的条目，reason 为其失败原因
+	retry  []T
+	reason error
+	// err 其余条目的错误，这些条目不再重试
+	err error
+}
+
+// batcher 缓冲条目，由后台 goroutine 定期或攒满一批时推送，失败时按指数退避重试。
+// Sync 与 Close 触发的推送只尝试一次，避免 Logger.Sync、Logger.Close 长时间阻塞
+type batcher[T any] struct {
+	batchOptions
+	// name 推送失败时输出到 stderr 的描述，如 loki push to http://loki:3100/loki/api/v1/push
+	name string
+	size func(item T) int
+	// send 推送一批条目，返回可以重试的条目及其余条目的错误
+	send func(items []T) batchResult[T]
+	// drop 不为 nil 时处理放弃重试的条目
+	drop func(items []T, reason error) error
+
+	mu            sync.Mutex
+	pending       []T
+	pendingBytes  int
+	bufferedBytes int
+
+	// pushMu 保证批次按顺序推送
+	pushMu   sync.Mutex
+	flush    chan struct{}
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// start 在 send 等回调设置完成后启动后台推送
+func (b *batcher[T]) start() {
+	b.flush = make(chan struct{}, 1)
+	b.stop = make(chan struct{})
+	b.done = make(chan struct{})
+	go b.run()
+}
+
+func (b *batcher[T]) add(item T) error {
+	size := b.size(item)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.bufferedBytes+size > b.maxBuffer {
+		return errBatchBufferFull
+	}
+	b.pending = append(b.pending, item)
+	b.pendingBytes += size
+	b.bufferedBytes += size
+	if len(b.pending) >= b.batchSize || b.pendingBytes >= b.batchBytes {
+		select {
+		case b.flush <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+func (b *batcher[T]) run() {
+	defer close(b.done)
+
+	ticker := time.NewTicker(b.batchWait)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-b.flush:
+		case <-b.stop:
+			return
+		}
+		if err := b.push(true); err != nil {
+			fmt.Fprintf(os.Stderr, "noop/log: %s failed: %v\n", b.name, err)
+		}
+	}
+}
+
+// push 取出当前缓冲的条目，按 batchSize、batchBytes 分批推送，retry 为 false 时每批只尝试一次
+func (b *batcher[T]) push(retry bool) error {
+	b.pushMu.Lock()
+	defer b.pushMu.Unlock()
+
+	b.mu.Lock()
+	items, size := b.pending, b.pendingBytes
+	b.pending, b.pendingBytes = nil, 0
+	b.mu.Unlock()
+	if len(items) == 0 {
+		return nil
+	}
+	defer func() {
+		b.mu.Lock()
+		b.bufferedBytes -= size
+		b.mu.Unlock()
+	}()
+
+	var err error
+	for len(items) != 0 {
+		n, batchBytes := 0, 0
+		for n < len(items) && n < b.batchSize && (n == 0 || batchBytes+b.size(items[n]) <= b.batchBytes) {
+			batchBytes += b.size(items[n])
+			n++
+		}
+		err = multierr.Append(err, b.pushBatch(items[:n], retry))
+		items = items[n:]
+	}
+	return err
+}
+
+func (b *batcher[T]) pushBatch(items []T, retry bool) error {
+	var errs error
+	backoff := b.minBackoff
+	for attempt := 0; ; attempt++ {
+		result := b.send(items)
+		errs = multierr.Append(errs, result.err)
+		if len(result.retry) == 0 {
+			return errs
+		}
+		if retry && attempt < b.maxRetries && b.wait(backoff) {
+			if backoff *= 2; backoff > b.maxBackoff {
+				backoff = b.maxBackoff
+			}
+			items = result.retry
+			continue
+		}
+		if b.drop != nil {
+			errs = multierr.Append(errs, b.drop(result.retry, result.reason))
+		}
+		return multierr.Append(errs, result.reason)
+	}
+}
+
+// wait 等待 d 后返回 true，关闭时立即返回 false，不再重试
+func (b *batcher[T]) wait(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-b.stop:
+		return false
+	}
+}
+
+// close 停止后台推送并推送剩余的条目
+func (b *batcher[T]) close() error {
+	b.stopOnce.Do(func() {
+		close(b.stop)
+	})
+	<-b.done
+	return b.push(false)
+}
@@ -0,0 +1,176 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.uber.org/multierr"
+	"go.uber.org/zap/zapcore"
+)
+
+// ConfigError 描述单项配置的错误，Build 返回的错误可通过 multierr.Errors 拆分为多个 ConfigError
+type ConfigError struct {
+	Field string
+	Err   error
+}
+
+func (e *ConfigError) Error() string {
+	return e.Field + ": " + e.Err.Error()
+}
+
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+// Build 校验全部配置并立即打开日志文件，所有问题合并为一个错误返回，
+// 与 Init 不同，配置有误时不会返回可用的 Logger
+func (c *Config) Build() (*Logger, error) {
+	if err := c.validate(); err != nil {
+		return nil, multierr.Append(err, c.checkFiles(err))
+	}
+
+	logger, writers, err := c.build()
+	err = multierr.Append(err, c.checkFiles(err))
+	if err == nil {
+		for i, file := range c.logFiles(logger.fileLevel) {
+			// lumberjack 在首次写入时才打开文件，写入空内容以便提前打开
+			if _, openErr := writers[i].Write(nil); openErr != nil {
+				err = multierr.Append(err, &ConfigError{Field: file.field, Err: openErr})
+			}
+		}
+	}
+	if err != nil {
+		for _, writer := range logger.writers {
+			_ = writer.Close()
+		}
+		return nil, err
+	}
+
+	c.start(logger)
+	return logger, nil
+}
+
+// checkFiles 探测 err 中未报告错误的日志文件能否打开，以便一次报告全部问题，
+// 文件名依赖 time_rotation，其有误时不再检查
+func (c *Config) checkFiles(err error) error {
+	failed := make(map[string]bool)
+	for _, e := range multierr.Errors(err) {
+		var configErr *ConfigError
+		if errors.As(e, &configErr) {
+			failed[configErr.Field] = true
+		}
+	}
+	if failed["time_rotation"] {
+		return nil
+	}
+
+	var checkErr error
+	now := time.Now()
+	for _, file := range c.logFiles(zapcore.Level(c.rollingConfig.level)) {
+		if failed[file.field] {
+			continue
+		}
+		if openErr := c.probeFile(file.filenameAt(now)); openErr != nil {
+			checkErr = multierr.Append(checkErr, &ConfigError{Field: file.field, Err: openErr})
+		}
+	}
+	return checkErr
+}
+
+// probeFile 以与写入相同的方式打开文件，检查完成后删除探测过程中创建的文件和目录，
+// Build 失败时不在磁盘上留下任何内容
+func (c *Config) probeFile(filename string) error {
+	created := ""
+	for path := filename; ; path = filepath.Dir(path) {
+		if _, err := os.Lstat(path); !os.IsNotExist(err) {
+			break
+		}
+		created = path
+		if filepath.Dir(path) == path {
+			break
+		}
+	}
+
+	writer := c.newLumberjackLogger(filename)
+	_, err := writer.Write(nil)
+	_ = writer.Close()
+	if created != "" {
+		_ = os.RemoveAll(created)
+	}
+	return err
+}
+
+func (c *Config) addError(field string, err error) {
+	c.errs = append(c.errs, &ConfigError{Field: field, Err: err})
+}
+
+func (c *Config) validate() error {
+	err := multierr.Combine(c.errs...)
+
+	if !validLevel(c.stdoutConfig.level) {
+		err = multierr.Append(err, &ConfigError{Field: "level", Err: fmt.Errorf("invalid stdout level %d", c.stdoutConfig.level)})
+	}
+	if !validLevel(c.rollingConfig.level) {
+		err = multierr.Append(err, &ConfigError{Field: "level", Err: fmt.Errorf("invalid file level %d", c.rollingConfig.level)})
+	}
+	if level := c.levelFilterFileConfig.warnLogLevel; level != nil && !validLevel(*level) {
+		err = multierr.Append(err, &ConfigError{Field: "warn_log", Err: fmt.Errorf("invalid level %d", *level)})
+	}
+	if level := c.levelFilterFileConfig.errorLogLevel; level != nil && !validLevel(*level) {
+		err = multierr.Append(err, &ConfigError{Field: "error_log", Err: fmt.Errorf("invalid level %d", *level)})
+	}
+
+	if value, ok := c.fieldsConfig.fields[HumanTime]; ok {
+		if _, ok := value.(*time.Location); !ok {
+			err = multierr.Append(err, &ConfigError{Field: "human_time", Err: fmt.Errorf("expect *time.Location, got %T", value)})
+		}
+	}
+
+	if rotationErr := c.Rotation().validate(); rotationErr != nil {
+		err = multierr.Append(err, &ConfigError{Field: "rotation", Err: rotationErr})
+	}
+	if retention := c.rollingConfig.retention; retention != nil {
+		if retentionErr := retention.validate(); retentionErr != nil {
+			err = multierr.Append(err, &ConfigError{Field: "retention", Err: retentionErr})
+		}
+	}
+	if rotation := c.rollingConfig.timeRotation; rotation != nil {
+		if rotationErr := rotation.validate(); rotationErr != nil {
+			err = multierr.Append(err, &ConfigError{Field: "time_rotation", Err: rotationErr})
+		}
+	}
+
+	for _, sink := range c.sinks {
+		if sinkErr := sink.validate(); sinkErr != nil {
+			err = multierr.Append(err, &ConfigError{Field: sink.field(), Err: sinkErr})
+		}
+	}
+
+	now := time.Now()
+	seen := make(map[string]string)
+	for _, file := range c.logFiles(zapcore.Level(c.rollingConfig.level)) {
+		filename := file.filenameAt(now)
+		if filename == "" {
+			err = multierr.Append(err, &ConfigError{Field: file.field, Err: errors.New("empty filename")})
+			continue
+		}
+		path, absErr := filepath.Abs(filename)
+		if absErr != nil {
+			err = multierr.Append(err, &ConfigError{Field: file.field, Err: absErr})
+			continue
+		}
+		if other, ok := seen[path]; ok {
+			err = multierr.Append(err, &ConfigError{Field: file.field, Err: fmt.Errorf("same file %s as %s", filename, other)})
+			continue
+		}
+		seen[path] = file.field
+	}
+	return err
+}
+
+func validLevel(level Level) bool {
+	return level >= _minLevel && level <= _maxLevel
+}
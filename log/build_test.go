@@ -0,0 +1,91 @@
+package log
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.uber.org/multierr"
+)
+
+func TestConfig_Build(t *testing.T) {
+	dir := t.TempDir()
+	logger, err := New().
+		WithFilename(filepath.Join(dir, "logs", "main.log")).
+		WithWarnLog(filepath.Join(dir, "logs", "warn.log")).
+		WithErrorLog(filepath.Join(dir, "logs", "error.log")).
+		Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	logger.Info("built logger")
+
+	// 文件在 Build 时即被创建，而不是首次写入时
+	for _, name := range []string{"main.log", "warn.log", "error.log"} {
+		if _, err := os.Stat(filepath.Join(dir, "logs", name)); err != nil {
+			t.Errorf("%s should be created by Build: %v", name, err)
+		}
+	}
+}
+
+func TestConfig_BuildErrors(t *testing.T) {
+	dir := t.TempDir()
+	blocker := filepath.Join(dir, "blocker")
+	if err := os.WriteFile(blocker, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := New().
+		WithFilename(filepath.Join(dir, "logs", "same.log")).
+		WithWarnLog(filepath.Join(dir, "logs", "same.log")).
+		WithErrorLog(filepath.Join(blocker, "error.log")).
+		WithFields(map[string]any{HumanTime: "Asia/Shanghai"}).
+		WithLevel(Level(42)).
+		Build()
+	if err == nil {
+		t.Fatal("Build() should fail")
+	}
+
+	fields := make(map[string]bool)
+	for _, e := range multierr.Errors(err) {
+		var configErr *ConfigError
+		if !errors.As(e, &configErr) {
+			t.Fatalf("unexpected error type %T: %v", e, e)
+		}
+		fields[configErr.Field] = true
+	}
+	for _, field := range []string{"warn_log", "error_log", "human_time", "level"} {
+		if !fields[field] {
+			t.Errorf("missing error for %s in %v", field, err)
+		}
+	}
+
+	// 探测时创建的文件和目录在 Build 失败后被删除
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("Build() left files behind: %v", entries)
+	}
+}
+
+func TestConfig_BuildOpenError(t *testing.T) {
+	dir := t.TempDir()
+	blocker := filepath.Join(dir, "blocker")
+	if err := os.WriteFile(blocker, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := New().WithFilename(filepath.Join(blocker, "app.log")).Build()
+	var configErr *ConfigError
+	if !errors.As(err, &configErr) || configErr.Field != "filename" {
+		t.Errorf("Build() error = %v, want filename error", err)
+	}
+}
+
+func TestConfig_InitIgnoresInvalidHumanTime(t *testing.T) {
+	dir := t.TempDir()
+	logger := New().
+		WithFilename(filepath.Join(dir, "app.log")).
+		WithFields(map[string]any{HumanTime: "Asia/Shanghai"}).
+		Init()
+	logger.Info("human time of wrong type should not panic")
+}
@@ -2,43 +2,63 @@ package log
 
 import (
 	"fmt"
+	"io"
 	"os"
-	"path"
-	"path/filepath"
-	"strings"
+	"sync"
 	"time"
 
+	"go.uber.org/multierr"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"gopkg.in/natefinch/lumberjack.v2"
 )
 
-var defaultLogger *Logger
-
 const DefaultFilename = "./app.log"
 const HumanTime = "_human_time"
 
+// DefaultName Default 创建的日志器名称
+const DefaultName = "default"
+
 // Logger 暴露的日志器结构体
 type Logger struct {
-	zapLogger *zap.Logger
-	config    *Config
+	zapLogger   *zap.Logger
+	config      *Config
+	stdoutLevel zap.AtomicLevel
+	fileLevel   zap.AtomicLevel
+	retention   *retentionManager
+	writers     []fileWriter
+	closeOnce   sync.Once
+	closeErr    error
+	// root With、Named 创建的子日志器指向 Init 返回的日志器，关闭时关闭的是它的输出
+	root *Logger
 }
 
 type Config struct {
+	name                  string
 	stdoutConfig          *StdoutConfig
 	rollingConfig         *FileConfig
 	fieldsConfig          *FieldsConfig
 	levelFilterFileConfig *LevelFilterFileConfig
+	errs                  []error
+	// setDefault 为 true 时 Init 总是替换全局实例
+	setDefault        bool
+	contextExtractors []ContextExtractor
+	sinks             []sinkConfig
 }
 
 type StdoutConfig struct {
-	level Level
+	level   Level
+	disable bool
+	writer  io.Writer
 }
 
 type FileConfig struct {
-	level    Level
-	encoding zapcore.EncoderConfig
-	logger   *lumberjack.Logger
+	level           Level
+	encoding        zapcore.EncoderConfig
+	logger          *lumberjack.Logger
+	defaultFilename bool
+	timeRotation    *timeRotation
+	retention       *RetentionPolicy
 }
 
 type FieldsConfig struct {
@@ -48,43 +68,139 @@ type FieldsConfig struct {
 type LevelFilterFileConfig struct {
 	warnLevelEnable  bool
 	warnLogFilename  string
+	warnLogLevel     *Level
 	errorLevelEnable bool
 	errorLogFilename string
+	errorLogLevel    *Level
 }
 
 func (c *Config) Init() *Logger {
-	var cores []zapcore.Core
-	consoleEncoder := zap.NewDevelopmentEncoderConfig()
-	consoleEncoder.EncodeLevel = zapcore.CapitalColorLevelEncoder
-	consoleCore := zapcore.NewCore(
-		zapcore.NewConsoleEncoder(consoleEncoder),
-		zapcore.AddSync(zapcore.Lock(os.Stdout)),
-		zapcore.Level(c.stdoutConfig.level),
-	)
+	logger, _, _ := c.build()
+	c.start(logger)
+	return logger
+}
 
-	fileCore := c.getCore(c.rollingConfig.logger.Filename, c.getSmallestLevelEnable())
-	fileCore = c.setFields(fileCore)
+// start 启动日志器的后台任务并注册到全局
+func (c *Config) start(logger *Logger) {
+	if logger.retention != nil {
+		logger.retention.start()
+	}
+	registerLogger(c.name, logger)
+
+	// Default 创建的实例总是替换全局实例，New 创建的实例只在尚未设置全局实例时使用
+	if c.setDefault {
+		SetDefault(logger)
+	} else {
+		defaultLogger.CompareAndSwap(nil, logger)
+	}
+}
+
+// logFile 描述一个需要写入的日志文件及其级别过滤规则
+type logFile struct {
+	field    string
+	filename string
+	level    string
+	rotation *timeRotation
+	enabler  zap.LevelEnablerFunc
+}
+
+// filenameAt 返回 t 时刻写入的文件名，按时间切换的文件会带上所在周期的起始时间
+func (f logFile) filenameAt(t time.Time) string {
+	if f.rotation == nil {
+		return stampFilename(f.filename, "", f.level)
+	}
+	start := f.rotation.start(t)
+	return stampFilename(f.filename, start.Format(f.rotation.layout()), f.level)
+}
 
-	cores = append(cores, consoleCore, fileCore)
+// logFiles 返回需要写入的日志文件，未单独设置级别的文件都受 level 约束
+func (c *Config) logFiles(level zapcore.LevelEnabler) []logFile {
+	files := []logFile{{
+		field:    "filename",
+		filename: c.rollingConfig.logger.Filename,
+		rotation: c.fileRotation(c.rollingConfig.defaultFilename),
+		enabler:  c.getSmallestLevelEnable(level),
+	}}
 
 	if c.levelFilterFileConfig.warnLevelEnable {
-		levelEnablerFunc := zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
-			if c.levelFilterFileConfig.errorLevelEnable {
-				return lvl == zapcore.WarnLevel
-			}
-			return lvl >= zapcore.WarnLevel
-		})
-		warnFileCore := c.getCore(c.levelFilterFileConfig.warnLogFilename, levelEnablerFunc)
-		warnFileCore = c.setFields(warnFileCore)
-		cores = append(cores, warnFileCore)
+		warnLevel := sinkLevel(c.levelFilterFileConfig.warnLogLevel, level)
+		files = append(files, c.levelFilterFile(logFile{
+			field:    "warn_log",
+			filename: c.levelFilterFileConfig.warnLogFilename,
+			level:    "warn",
+			enabler: func(lvl zapcore.Level) bool {
+				if !warnLevel.Enabled(lvl) {
+					return false
+				}
+				if c.levelFilterFileConfig.errorLevelEnable {
+					return lvl == zapcore.WarnLevel
+				}
+				return lvl >= zapcore.WarnLevel
+			},
+		}))
 	}
 
 	if c.levelFilterFileConfig.errorLevelEnable {
-		errorFileCore := c.getCore(c.levelFilterFileConfig.errorLogFilename, func(lvl zapcore.Level) bool {
-			return lvl >= zapcore.ErrorLevel
-		})
-		errorFileCore = c.setFields(errorFileCore)
-		cores = append(cores, errorFileCore)
+		errorLevel := sinkLevel(c.levelFilterFileConfig.errorLogLevel, level)
+		files = append(files, c.levelFilterFile(logFile{
+			field:    "error_log",
+			filename: c.levelFilterFileConfig.errorLogFilename,
+			level:    "error",
+			enabler: func(lvl zapcore.Level) bool {
+				return errorLevel.Enabled(lvl) && lvl >= zapcore.ErrorLevel
+			},
+		}))
+	}
+	return files
+}
+
+// levelFilterFile 未指定文件名的 warn、error 日志文件使用带级别后缀的默认文件名，
+// 指定了文件名时按原样使用
+func (c *Config) levelFilterFile(file logFile) logFile {
+	if file.filename == "" {
+		file.filename = DefaultFilename
+		file.rotation = c.fileRotation(true)
+		return file
+	}
+	file.level = ""
+	file.rotation = c.fileRotation(false)
+	return file
+}
+
+// sinkLevel 单独设置了级别的文件使用固定级别，否则跟随 fallback
+func sinkLevel(level *Level, fallback zapcore.LevelEnabler) zapcore.LevelEnabler {
+	if level != nil {
+		return zapcore.Level(*level)
+	}
+	return fallback
+}
+
+// build 按配置构造日志器，返回的 writers 与 logFiles 一一对应，
+// 无法创建的 WithSink 输出被跳过，错误随 err 返回
+func (c *Config) build() (logger *Logger, writers []fileWriter, err error) {
+	// stdout 与所有文件分别共享一个原子级别，运行时可通过 Logger.SetLevel 等方法调整
+	stdoutLevel := zap.NewAtomicLevelAt(zapcore.Level(c.stdoutConfig.level))
+	fileLevel := zap.NewAtomicLevelAt(zapcore.Level(c.rollingConfig.level))
+
+	var cores []zapcore.Core
+	if !c.stdoutConfig.disable {
+		cores = append(cores, c.getConsoleCore(stdoutLevel))
+	}
+
+	files := c.logFiles(fileLevel)
+	for _, file := range files {
+		writer := c.newFileWriter(file)
+		fileCore := c.getCore(writer, file.enabler)
+		fileCore = c.setFields(fileCore)
+		cores = append(cores, fileCore)
+		writers = append(writers, writer)
+	}
+
+	sinkCores, sinks, err := c.openSinks()
+	cores = append(cores, sinkCores...)
+	closers := writers
+	for _, sink := range sinks {
+		closers = append(closers, sink)
 	}
 
 	core := zapcore.NewTee(cores...)
@@ -95,17 +211,17 @@ func (c *Config) Init() *Logger {
 		zap.AddStacktrace(zap.ErrorLevel),
 	)
 
-	logger := &Logger{
-		zapLogger: zapLogger,
-		config:    c,
+	logger = &Logger{
+		zapLogger:   zapLogger,
+		config:      c,
+		stdoutLevel: stdoutLevel,
+		fileLevel:   fileLevel,
+		writers:     closers,
 	}
-
-	// 如果是默认实例，更新全局变量
-	if defaultLogger == nil {
-		defaultLogger = logger
+	if c.rollingConfig.retention != nil {
+		logger.retention = newRetentionManager(*c.rollingConfig.retention, files, logger)
 	}
-
-	return logger
+	return logger, writers, err
 }
 
 // New 创建一个新的日志实例
@@ -117,28 +233,33 @@ func New() *Config {
 		rollingConfig: &FileConfig{
 			encoding: zapcore.EncoderConfig{},
 			logger: &lumberjack.Logger{
-				Filename: getLogFilename(DefaultFilename, ""),
+				Filename: DefaultFilename,
 				MaxSize:  500, // megabytes
 				MaxAge:   30,  // days
 			},
+			defaultFilename: true,
 		},
 		fieldsConfig:          &FieldsConfig{},
 		levelFilterFileConfig: &LevelFilterFileConfig{},
 	}
 }
 
+// Default 创建默认实例的配置，Init 后替换全局函数使用的实例
 func Default() *Config {
 	return &Config{
+		name:       DefaultName,
+		setDefault: true,
 		stdoutConfig: &StdoutConfig{
 			level: DebugLevel,
 		},
 		rollingConfig: &FileConfig{
 			encoding: zapcore.EncoderConfig{},
 			logger: &lumberjack.Logger{
-				Filename: getLogFilename(DefaultFilename, ""),
+				Filename: DefaultFilename,
 				MaxSize:  500, // megabytes
 				MaxAge:   30,  // days
 			},
+			defaultFilename: true,
 		},
 		fieldsConfig:          &FieldsConfig{},
 		levelFilterFileConfig: &LevelFilterFileConfig{},
@@ -147,7 +268,9 @@ func Default() *Config {
 
 // Logger 方法
 func (l *Logger) Trace(msg string, fields ...zap.Field) {
-	l.zapLogger.Debug(msg, fields...)
+	if ce := l.zapLogger.Check(zapcore.Level(TraceLevel), msg); ce != nil {
+		ce.Write(fields...)
+	}
 }
 
 func (l *Logger) Debug(msg string, fields ...zap.Field) {
@@ -174,6 +297,21 @@ func (l *Logger) Panic(msg string, fields ...zap.Field) {
 	l.zapLogger.Panic(msg, fields...)
 }
 
+func (l *Logger) Tracef(template string, args ...any) {
+	if ce := l.zapLogger.Check(zapcore.Level(TraceLevel), template); ce != nil {
+		ce.Message = sprintf(template, args)
+		ce.Write()
+	}
+}
+
+// sprintf 与 zap 的 SugaredLogger 一致，没有参数时不格式化 template
+func sprintf(template string, args []any) string {
+	if len(args) == 0 {
+		return template
+	}
+	return fmt.Sprintf(template, args...)
+}
+
 func (l *Logger) Debugf(template string, args ...any) {
 	l.zapLogger.Sugar().Debugf(template, args...)
 }
@@ -198,13 +336,104 @@ func (l *Logger) Panicf(template string, args ...any) {
 	l.zapLogger.Sugar().Panicf(template, args...)
 }
 
+// SetLevel 运行时调整日志级别，对 stdout 和日志文件同时生效
+func (l *Logger) SetLevel(level Level) {
+	l.stdoutLevel.SetLevel(zapcore.Level(level))
+	l.fileLevel.SetLevel(zapcore.Level(level))
+}
+
+// Level 返回 stdout 与日志文件中较低的级别，即实际会输出的最低级别
+func (l *Logger) Level() Level {
+	if l.stdoutLevel.Level() < l.fileLevel.Level() {
+		return l.StdoutLevel()
+	}
+	return l.FileLevel()
+}
+
+// SetStdoutLevel 运行时调整 stdout 的日志级别
+func (l *Logger) SetStdoutLevel(level Level) {
+	l.stdoutLevel.SetLevel(zapcore.Level(level))
+}
+
+// StdoutLevel 返回 stdout 当前的日志级别
+func (l *Logger) StdoutLevel() Level {
+	return Level(l.stdoutLevel.Level())
+}
+
+// SetFileLevel 运行时调整日志文件的级别，单独设置了级别的 warn、error 日志文件不受影响
+func (l *Logger) SetFileLevel(level Level) {
+	l.fileLevel.SetLevel(zapcore.Level(level))
+}
+
+// FileLevel 返回日志文件当前的级别
+func (l *Logger) FileLevel() Level {
+	return Level(l.fileLevel.Level())
+}
+
 // ZapLogger 暴露底层的 zap.Logger
 func (l *Logger) ZapLogger() *zap.Logger {
 	return l.zapLogger
 }
 
+// With 返回带有 fields 的子日志器，子日志器与 l 共享输出和级别
+func (l *Logger) With(fields ...zap.Field) *Logger {
+	return l.child(l.zapLogger.With(fields...))
+}
+
+// Named 返回添加了名称的子日志器，多次调用时名称以 . 连接，子日志器与 l 共享输出和级别
+func (l *Logger) Named(name string) *Logger {
+	return l.child(l.zapLogger.Named(name))
+}
+
+func (l *Logger) child(zapLogger *zap.Logger) *Logger {
+	root := l
+	if l.root != nil {
+		root = l.root
+	}
+	return &Logger{
+		zapLogger:   zapLogger,
+		config:      l.config,
+		stdoutLevel: l.stdoutLevel,
+		fileLevel:   l.fileLevel,
+		root:        root,
+	}
+}
+
+// Sync 将缓冲中的日志刷新到所有输出
+func (l *Logger) Sync() error {
+	return l.zapLogger.Sync()
+}
+
+// Close 停止后台清理，刷新并关闭 Init 时打开的所有日志文件，多次调用只关闭一次。
+// 子日志器的 Close 会关闭共享的输出，Close 之后不应再使用该日志器及其子日志器，否则日志文件会被重新打开
+func (l *Logger) Close() error {
+	if l.root != nil {
+		return l.root.Close()
+	}
+	l.closeOnce.Do(func() {
+		if l.retention != nil {
+			l.retention.close()
+		}
+		unregisterLogger(l.config.name, l)
+
+		err := l.Sync()
+		for _, writer := range l.writers {
+			err = multierr.Append(err, writer.Close())
+		}
+		l.closeErr = err
+	})
+	return l.closeErr
+}
+
+// WithName 设置日志器名称，Init 后可通过 LevelsHandler 按名称调整级别
+func (c *Config) WithName(name string) *Config {
+	c.name = name
+	return c
+}
+
 func (c *Config) WithFilename(filename string) *Config {
 	c.rollingConfig.logger.Filename = filename
+	c.rollingConfig.defaultFilename = false
 	return c
 }
 
@@ -214,6 +443,36 @@ func (c *Config) WithLevel(level Level) *Config {
 	return c
 }
 
+// WithStdoutLevel 单独设置 stdout 的日志级别
+func (c *Config) WithStdoutLevel(level Level) *Config {
+	c.stdoutConfig.level = level
+	return c
+}
+
+// WithFileLevel 单独设置日志文件的级别，未通过 WithWarnLogLevel、WithErrorLogLevel 单独设置的 warn、error 日志文件也使用该级别
+func (c *Config) WithFileLevel(level Level) *Config {
+	c.rollingConfig.level = level
+	return c
+}
+
+// WithoutStdout 不输出到控制台，只写日志文件
+func (c *Config) WithoutStdout() *Config {
+	c.stdoutConfig.disable = true
+	return c
+}
+
+// WithStderr 控制台输出改为 os.Stderr
+func (c *Config) WithStderr() *Config {
+	return c.WithConsoleWriter(os.Stderr)
+}
+
+// WithConsoleWriter 控制台输出改为指定的 writer，writer 不是终端时不输出颜色
+func (c *Config) WithConsoleWriter(writer io.Writer) *Config {
+	c.stdoutConfig.disable = false
+	c.stdoutConfig.writer = writer
+	return c
+}
+
 func (c *Config) WithFields(fields map[string]any) *Config {
 	c.addFields(fields)
 	return c
@@ -231,40 +490,34 @@ func (c *Config) WithHumanTime(location *time.Location) *Config {
 
 func (c *Config) WithWarnLog(optionWarnLogFilename string) *Config {
 	c.levelFilterFileConfig.warnLevelEnable = true
-	c.levelFilterFileConfig.warnLogFilename = getLogLevelFilterFilename(optionWarnLogFilename, "warn")
+	c.levelFilterFileConfig.warnLogFilename = optionWarnLogFilename
 	return c
 }
 
 func (c *Config) WithErrorLog(optionErrorLogFilename string) *Config {
 	c.levelFilterFileConfig.errorLevelEnable = true
-	c.levelFilterFileConfig.errorLogFilename = getLogLevelFilterFilename(optionErrorLogFilename, "error")
+	c.levelFilterFileConfig.errorLogFilename = optionErrorLogFilename
 	return c
 }
 
-func getLogFilename(rawFilename string, level string) string {
-	if rawFilename == "" {
-		return rawFilename
-	}
-	filename := filepath.Base(rawFilename)
-	suffix := path.Ext(filename)
-	filenameOnly := strings.TrimSuffix(filename, suffix)
-	filenameOnly = fmt.Sprintf(filenameOnly + "_" + time.Now().In(time.Local).Format("2006-01-02"))
-	if level != "" {
-		level = "_" + level
-	}
-	return strings.ReplaceAll(rawFilename, filename, filenameOnly+level+suffix)
+// WithWarnLogLevel 为 warn 日志文件设置独立的级别，不再跟随日志文件级别及其运行时调整
+func (c *Config) WithWarnLogLevel(level Level) *Config {
+	c.levelFilterFileConfig.warnLogLevel = &level
+	return c
 }
 
-func getLogLevelFilterFilename(optionLogFilterFilename string, level string) string {
-	if optionLogFilterFilename == "" {
-		optionLogFilterFilename = getLogFilename(DefaultFilename, level)
-	}
-	return optionLogFilterFilename
+// WithErrorLogLevel 为 error 日志文件设置独立的级别，不再跟随日志文件级别及其运行时调整
+func (c *Config) WithErrorLogLevel(level Level) *Config {
+	c.levelFilterFileConfig.errorLogLevel = &level
+	return c
 }
 
 func (c *Config) transformFields() []zapcore.Field {
 	var zapFields []zapcore.Field
 	for k, v := range c.fieldsConfig.fields {
+		if k == HumanTime {
+			continue
+		}
 		zapFields = append(zapFields, zap.Any(k, v))
 	}
 	return zapFields
@@ -287,28 +540,90 @@ func (c *Config) setFields(core zapcore.Core) zapcore.Core {
 	return core
 }
 
-func (c *Config) getCore(logFilename string, levelEnablerFunc zap.LevelEnablerFunc) zapcore.Core {
-	fileWriter := zapcore.AddSync(&lumberjack.Logger{
-		Filename: logFilename,
-		MaxSize:  c.rollingConfig.logger.MaxSize, // megabytes
-		MaxAge:   c.rollingConfig.logger.MaxAge,  // days
-	})
-	fileEncoder := zap.NewProductionEncoderConfig()
-	if timeLocation, ok := c.fieldsConfig.fields[HumanTime]; ok {
-		fileEncoder.EncodeTime = func(t time.Time, enc zapcore.PrimitiveArrayEncoder) {
-			enc.AppendString(t.In(timeLocation.(*time.Location)).Format("2006-01-02 15:04:05.000"))
+func (c *Config) getConsoleCore(level zapcore.LevelEnabler) zapcore.Core {
+	writer := c.stdoutConfig.writer
+	if writer == nil {
+		writer = os.Stdout
+	}
+	consoleEncoder := consoleEncoderConfig()
+	// 只有输出到终端时才使用颜色，避免重定向到文件或管道时混入转义字符
+	if isTerminal(writer) {
+		consoleEncoder.EncodeLevel = capitalColorLevelEncoder
+	}
+	return zapcore.NewCore(
+		zapcore.NewConsoleEncoder(consoleEncoder),
+		zapcore.Lock(consoleSyncer(writer)),
+		level,
+	)
+}
+
+func consoleEncoderConfig() zapcore.EncoderConfig {
+	consoleEncoder := zap.NewDevelopmentEncoderConfig()
+	consoleEncoder.EncodeLevel = capitalLevelEncoder
+	return consoleEncoder
+}
+
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// consoleSyncer 终端和管道不支持 fsync，Sync 时会返回 EINVAL 等错误，只有普通文件才需要 Sync
+func consoleSyncer(w io.Writer) zapcore.WriteSyncer {
+	if f, ok := w.(*os.File); ok {
+		if info, err := f.Stat(); err != nil || !info.Mode().IsRegular() {
+			return zapcore.AddSync(struct{ io.Writer }{f})
 		}
-		delete(c.fieldsConfig.fields, HumanTime)
 	}
+	return zapcore.AddSync(w)
+}
+
+func (c *Config) newFileWriter(file logFile) fileWriter {
+	if file.rotation == nil {
+		return c.newLumberjackLogger(file.filenameAt(time.Now()))
+	}
+	return newTimeRotateWriter(file, c.newLumberjackLogger)
+}
+
+func (c *Config) newLumberjackLogger(logFilename string) *lumberjack.Logger {
+	return &lumberjack.Logger{
+		Filename:   logFilename,
+		MaxSize:    c.rollingConfig.logger.MaxSize, // megabytes
+		MaxAge:     c.rollingConfig.logger.MaxAge,  // days
+		MaxBackups: c.rollingConfig.logger.MaxBackups,
+		Compress:   c.rollingConfig.logger.Compress,
+		LocalTime:  c.rollingConfig.logger.LocalTime,
+	}
+}
+
+func (c *Config) getCore(fileWriter io.Writer, levelEnablerFunc zap.LevelEnablerFunc) zapcore.Core {
 	return zapcore.NewCore(
-		zapcore.NewJSONEncoder(fileEncoder),
+		zapcore.NewJSONEncoder(c.fileEncoderConfig()),
 		zapcore.AddSync(fileWriter),
 		levelEnablerFunc,
 	)
 }
 
-func (c *Config) getSmallestLevelEnable() zap.LevelEnablerFunc {
+func (c *Config) fileEncoderConfig() zapcore.EncoderConfig {
+	fileEncoder := zap.NewProductionEncoderConfig()
+	fileEncoder.EncodeLevel = lowercaseLevelEncoder
+	if timeLocation, ok := c.fieldsConfig.fields[HumanTime].(*time.Location); ok {
+		fileEncoder.EncodeTime = func(t time.Time, enc zapcore.PrimitiveArrayEncoder) {
+			enc.AppendString(t.In(timeLocation).Format("2006-01-02 15:04:05.000"))
+		}
+	}
+	return fileEncoder
+}
+
+func (c *Config) getSmallestLevelEnable(level zapcore.LevelEnabler) zap.LevelEnablerFunc {
 	return func(lvl zapcore.Level) bool {
+		if !level.Enabled(lvl) {
+			return false
+		}
 		if c.levelFilterFileConfig.errorLevelEnable && c.levelFilterFileConfig.warnLevelEnable {
 			return lvl < zapcore.WarnLevel
 		} else if c.levelFilterFileConfig.errorLevelEnable {
@@ -316,6 +631,6 @@ func (c *Config) getSmallestLevelEnable() zap.LevelEnablerFunc {
 		} else if c.levelFilterFileConfig.warnLevelEnable {
 			return lvl < zapcore.WarnLevel
 		}
-		return lvl >= zapcore.Level(c.rollingConfig.level)
+		return true
 	}
 }
@@ -0,0 +1,93 @@
+package log
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// ContextExtractor 从 context 中提取需要随日志输出的字段，如 trace id、租户、用户，
+// 没有可提取的内容时返回 nil
+type ContextExtractor func(ctx context.Context) []zap.Field
+
+type loggerContextKey struct{}
+
+// WithContext 返回携带 logger 的 context，之后可通过 FromContext 取出
+func WithContext(ctx context.Context, logger *Logger) context.Context {
+	return context.WithValue(ctx, loggerContextKey{}, logger)
+}
+
+// FromContext 返回 ctx 中携带的日志器，没有时返回默认实例
+func FromContext(ctx context.Context) *Logger {
+	if ctx != nil {
+		if logger, ok := ctx.Value(loggerContextKey{}).(*Logger); ok && logger != nil {
+			return logger
+		}
+	}
+	return L()
+}
+
+// WithContextExtractor 添加从 context 中提取字段的函数，XxxCtx 方法输出日志时按添加顺序调用，
+// 提取的字段排在调用方传入的字段之前
+func (c *Config) WithContextExtractor(extractors ...ContextExtractor) *Config {
+	c.contextExtractors = append(c.contextExtractors, extractors...)
+	return c
+}
+
+// contextFields 只在日志级别开启时调用，避免提取不会输出的字段
+func (l *Logger) contextFields(ctx context.Context, fields []zap.Field) []zap.Field {
+	if ctx == nil || len(l.config.contextExtractors) == 0 {
+		return fields
+	}
+	var extracted []zap.Field
+	for _, extractor := range l.config.contextExtractors {
+		extracted = append(extracted, extractor(ctx)...)
+	}
+	if len(extracted) == 0 {
+		return fields
+	}
+	return append(extracted, fields...)
+}
+
+func (l *Logger) TraceCtx(ctx context.Context, msg string, fields ...zap.Field) {
+	if ce := l.zapLogger.Check(zapcore.Level(TraceLevel), msg); ce != nil {
+		ce.Write(l.contextFields(ctx, fields)...)
+	}
+}
+
+func (l *Logger) DebugCtx(ctx context.Context, msg string, fields ...zap.Field) {
+	if ce := l.zapLogger.Check(zapcore.DebugLevel, msg); ce != nil {
+		ce.Write(l.contextFields(ctx, fields)...)
+	}
+}
+
+func (l *Logger) InfoCtx(ctx context.Context, msg string, fields ...zap.Field) {
+	if ce := l.zapLogger.Check(zapcore.InfoLevel, msg); ce != nil {
+		ce.Write(l.contextFields(ctx, fields)...)
+	}
+}
+
+func (l *Logger) WarnCtx(ctx context.Context, msg string, fields ...zap.Field) {
+	if ce := l.zapLogger.Check(zapcore.WarnLevel, msg); ce != nil {
+		ce.Write(l.contextFields(ctx, fields)...)
+	}
+}
+
+func (l *Logger) ErrorCtx(ctx context.Context, msg string, fields ...zap.Field) {
+	if ce := l.zapLogger.Check(zapcore.ErrorLevel, msg); ce != nil {
+		ce.Write(l.contextFields(ctx, fields)...)
+	}
+}
+
+func (l *Logger) FatalCtx(ctx context.Context, msg string, fields ...zap.Field) {
+	if ce := l.zapLogger.Check(zapcore.FatalLevel, msg); ce != nil {
+		ce.Write(l.contextFields(ctx, fields)...)
+	}
+}
+
+func (l *Logger) PanicCtx(ctx context.Context, msg string, fields ...zap.Field) {
+	if ce := l.zapLogger.Check(zapcore.PanicLevel, msg); ce != nil {
+		ce.Write(l.contextFields(ctx, fields)...)
+	}
+}
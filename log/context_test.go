@@ -0,0 +1,85 @@
+package log
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+type tenantKey struct{}
+
+func tenantExtractor(ctx context.Context) []zap.Field {
+	if tenant, ok := ctx.Value(tenantKey{}).(string); ok {
+		return []zap.Field{zap.String("tenant", tenant)}
+	}
+	return nil
+}
+
+func TestContextLogging(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "ctx.log")
+	calls := 0
+	logger := New().
+		WithFilename(filename).
+		WithoutStdout().
+		WithLevel(InfoLevel).
+		WithContextExtractor(tenantExtractor, func(ctx context.Context) []zap.Field {
+			calls++
+			return nil
+		}).
+		Init()
+	defer logger.Close()
+
+	ctx := context.WithValue(context.Background(), tenantKey{}, "acme")
+	logger.InfoCtx(ctx, "logger ctx", zap.String("user", "alice"))
+	logger.DebugCtx(ctx, "filtered")
+	if calls != 1 {
+		t.Errorf("extractors should only run for enabled levels, got %d calls", calls)
+	}
+
+	ctx = WithContext(ctx, logger.With(zap.String("request_id", "r-1")))
+	if FromContext(ctx).root != logger {
+		t.Error("FromContext should return the logger carried by ctx")
+	}
+	WarnCtx(ctx, "package ctx")
+
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines: %s", len(lines), content)
+	}
+	for _, want := range []string{`"tenant":"acme","user":"alice"`, `"caller":"log/context_test.go`} {
+		if !strings.Contains(lines[0], want) {
+			t.Errorf("%s should contain %s", lines[0], want)
+		}
+	}
+	for _, want := range []string{`"request_id":"r-1"`, `"tenant":"acme"`, `"caller":"log/context_test.go`} {
+		if !strings.Contains(lines[1], want) {
+			t.Errorf("%s should contain %s", lines[1], want)
+		}
+	}
+}
+
+func TestFromContextDefault(t *testing.T) {
+	previous := defaultLogger.Load()
+	defer SetDefault(previous)
+
+	SetDefault(nil)
+	if FromContext(context.Background()) != nopLogger {
+		t.Error("FromContext without logger or default should discard logs")
+	}
+	InfoCtx(context.Background(), "dropped")
+
+	logger := New().WithFilename(filepath.Join(t.TempDir(), "default.log")).WithoutStdout().Init()
+	defer logger.Close()
+	SetDefault(logger)
+	if FromContext(context.Background()) != logger {
+		t.Error("FromContext should fall back to the default logger")
+	}
+}
@@ -0,0 +1,442 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// DefaultElasticsearchIndex 未指定时使用的索引前缀
+	DefaultElasticsearchIndex = "logs"
+	// DefaultElasticsearchIndexDate 索引日期后缀的默认格式，按 UTC 每天一个索引
+	DefaultElasticsearchIndexDate = "2006.01.02"
+
+	// ecsVersion 输出的 Elastic Common Schema 版本
+	ecsVersion = "8.11.0"
+)
+
+func init() {
+	for _, scheme := range []string{"elasticsearch+http", "elasticsearch+https", "opensearch+http", "opensearch+https"} {
+		if err := RegisterSink(scheme, newElasticsearchSink); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// ElasticsearchOptions WithElasticsearch 的选项，除 URL 外的零值使用默认值
+type ElasticsearchOptions struct {
+	// URL 集群地址，如 http://user:password@es:9200，用户名密码通过 Basic 认证发送
+	URL string
+	// Index 索引前缀，实际写入 <Index>-<日期> 索引，为空时使用 DefaultElasticsearchIndex
+	Index string
+	// IndexDateLayout 日期后缀的 time 格式，按日志时间的 UTC 时间生成，为空时使用 DefaultElasticsearchIndexDate
+	IndexDateLayout string
+	// ECS 为 true 时按 Elastic Common Schema 输出 @timestamp、log.level、message 等字段，
+	// 否则文档与日志文件的 JSON 格式相同
+	ECS bool
+	// APIKey 不为空时通过 Authorization: ApiKey 请求头认证
+	APIKey string
+	// BatchSize 每批最多的文档数，默认 500
+	BatchSize int
+	// BatchBytes 每批最多的字节数，默认 5MB
+	BatchBytes int
+	// BatchWait 未攒满一批时最长的等待时间，默认 1s
+	BatchWait time.Duration
+	// MaxBufferBytes 等待写入及写入中的文档占用的最大字节数，超出时丢弃新的日志，默认 16MB
+	MaxBufferBytes int
+	// MaxRetries 请求失败或文档返回 429 时的最大重试次数，默认 5，重试间隔从 500ms 开始指数增长，最长 30s
+	MaxRetries int
+	// DeadLetter 被集群拒绝或重试后仍失败的文档写入的文件，为空时丢弃
+	DeadLetter string
+	// Level 写入的最低级别
+	Level Level
+}
+
+// WithElasticsearch 添加通过 _bulk 接口批量写入 Elasticsearch 或 OpenSearch 的输出，写入在后台进行，
+// 单个文档失败时只重试或转存该文档。等价于使用 elasticsearch+http、elasticsearch+https、
+// opensearch+http、opensearch+https URL 调用 WithSink，
+// 如 elasticsearch+http://es:9200?index=api&ecs=true&dead_letter=logs/es-dead.log
+func (c *Config) WithElasticsearch(opts ElasticsearchOptions) *Config {
+	u, err := url.Parse(opts.URL)
+	if err != nil {
+		c.addError("elasticsearch", urlParseError(err))
+		return c
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		c.addError("elasticsearch", fmt.Errorf("unsupported scheme %q in %s", u.Scheme, redactURL(u)))
+		return c
+	}
+	u.Scheme = "elasticsearch+" + u.Scheme
+
+	query := u.Query()
+	for name, value := range map[string]string{
+		"index":       opts.Index,
+		"index_date":  opts.IndexDateLayout,
+		"api_key":     opts.APIKey,
+		"dead_letter": opts.DeadLetter,
+	} {
+		if value != "" {
+			query.Set(name, value)
+		}
+	}
+	if opts.ECS {
+		query.Set("ecs", "true")
+	}
+	setBatchQuery(query, opts.BatchSize, opts.BatchBytes, opts.MaxBufferBytes, opts.MaxRetries, opts.BatchWait)
+	u.RawQuery = query.Encode()
+	return c.WithSink(u.String(), opts.Level, EncodingJSON)
+}
+
+// elasticsearchSink 缓冲 _bulk 请求的文档，由后台 goroutine 定期或攒满一批时写入
+type elasticsearchSink struct {
+	endpoint   string
+	client     *http.Client
+	username   string
+	password   string
+	apiKey     string
+	index      string
+	indexDate  string
+	ecs        bool
+	deadLetter Sink
+	batcher    *batcher[bulkItem]
+}
+
+// bulkItem 一个待写入的文档
+type bulkItem struct {
+	index    string
+	document []byte
+}
+
+func (i bulkItem) size() int {
+	return len(i.index) + len(i.document)
+}
+
+func newElasticsearchSink(u *url.URL) (Sink, error) {
+	query := u.Query()
+	s := &elasticsearchSink{
+		client:    &http.Client{Timeout: 30 * time.Second},
+		apiKey:    query.Get("api_key"),
+		index:     DefaultElasticsearchIndex,
+		indexDate: DefaultElasticsearchIndexDate,
+		batcher: &batcher[bulkItem]{
+			batchOptions: batchOptions{
+				batchSize:  500,
+				batchBytes: 5 << 20,
+				batchWait:  time.Second,
+				maxBuffer:  16 << 20,
+				maxRetries: 5,
+				minBackoff: 500 * time.Millisecond,
+				maxBackoff: 30 * time.Second,
+			},
+			size: bulkItem.size,
+		},
+	}
+
+	endpoint := *u
+	endpoint.Scheme = u.Scheme[strings.Index(u.Scheme, "+")+1:]
+	endpoint.RawQuery = ""
+	endpoint.User = nil
+	endpoint.Path = strings.TrimSuffix(endpoint.Path, "/") + "/_bulk"
+	if endpoint.Host == "" {
+		return nil, errors.New("empty host")
+	}
+	s.endpoint = endpoint.String()
+	if u.User != nil {
+		s.username = u.User.Username()
+		s.password, _ = u.User.Password()
+	}
+
+	if index := query.Get("index"); index != "" {
+		if index != strings.ToLower(index) || strings.ContainsAny(index, `\/*?"<>| ,#:`) {
+			return nil, fmt.Errorf("invalid index %q", index)
+		}
+		s.index = index
+	}
+	if layout := query.Get("index_date"); layout != "" {
+		s.indexDate = layout
+	}
+	if value := query.Get("ecs"); value != "" {
+		ecs, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ecs %q", value)
+		}
+		s.ecs = ecs
+	}
+	if err := s.batcher.parseBatchQuery(query); err != nil {
+		return nil, err
+	}
+	if filename := query.Get("dead_letter"); filename != "" {
+		deadLetter, err := newFileSink(&url.URL{Scheme: "file", Path: filename})
+		if err != nil {
+			return nil, fmt.Errorf("dead_letter: %w", err)
+		}
+		s.deadLetter = deadLetter
+	}
+
+	s.batcher.name = "elasticsearch bulk to " + s.endpoint
+	s.batcher.send = s.send
+	s.batcher.drop = func(items []bulkItem, reason error) error {
+		return s.writeDeadLetter(items, nil, reason.Error())
+	}
+	s.batcher.start()
+	return s, nil
+}
+
+func (s *elasticsearchSink) Core(enc zapcore.Encoder, level zapcore.LevelEnabler, fields map[string]any) zapcore.Core {
+	// ECS 文档直接写入 fields，JSON 文档与日志文件一样将其作为字段输出
+	var static []zapcore.Field
+	if !s.ecs {
+		names := make([]string, 0, len(fields))
+		for name := range fields {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			static = append(static, zap.Any(name, fields[name]))
+		}
+	}
+	return &entryCore{
+		LevelEnabler: level,
+		fields:       static,
+		write: func(entry zapcore.Entry, entryFields []zapcore.Field) error {
+			if s.ecs {
+				document, err := ecsDocument(entry, fields, entryFields)
+				if err != nil {
+					return err
+				}
+				return s.add(s.indexName(entry.Time), document)
+			}
+			line, err := enc.EncodeEntry(entry, entryFields)
+			if err != nil {
+				return err
+			}
+			defer line.Free()
+			return s.add(s.indexName(entry.Time), bytes.TrimRight(line.Bytes(), "\n"))
+		},
+		sync: s.Sync,
+	}
+}
+
+// indexName 按日志时间生成 <index>-<日期> 形式的索引名
+func (s *elasticsearchSink) indexName(t time.Time) string {
+	return s.index + "-" + t.UTC().Format(s.indexDate)
+}
+
+// ecsDocument 按 Elastic Common Schema 生成文档，WithFields 与日志的字段写在顶层，
+// 字符串类型的 error 字段转换为 error.message
+func ecsDocument(entry zapcore.Entry, static map[string]any, fields []zapcore.Field) ([]byte, error) {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, field := range fields {
+		field.AddTo(enc)
+	}
+	document := make(map[string]any, len(static)+len(enc.Fields)+4)
+	for name, value := range static {
+		document[name] = value
+	}
+	for name, value := range enc.Fields {
+		document[name] = value
+	}
+
+	errorFields := map[string]any{}
+	if message, ok := document["error"].(string); ok {
+		errorFields["message"] = message
+	}
+	if entry.Stack != "" {
+		errorFields["stack_trace"] = entry.Stack
+	}
+	if len(errorFields) != 0 {
+		document["error"] = errorFields
+	}
+
+	logFields := map[string]any{"level": Level(entry.Level).String()}
+	if entry.LoggerName != "" {
+		logFields["logger"] = entry.LoggerName
+	}
+	if entry.Caller.Defined {
+		origin := map[string]any{
+			"file": map[string]any{"name": entry.Caller.File, "line": entry.Caller.Line},
+		}
+		if entry.Caller.Function != "" {
+			origin["function"] = entry.Caller.Function
+		}
+		logFields["origin"] = origin
+	}
+	document["log"] = logFields
+	document["@timestamp"] = entry.Time.UTC().Format("2006-01-02T15:04:05.000000Z07:00")
+	document["message"] = entry.Message
+	document["ecs"] = map[string]any{"version": ecsVersion}
+	return json.Marshal(document)
+}
+
+// Write 将 p 作为文档写入当前日期的索引，p 需要是一个 JSON 对象
+func (s *elasticsearchSink) Write(p []byte) (int, error) {
+	if err := s.add(s.indexName(time.Now()), bytes.TrimRight(p, "\n")); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (s *elasticsearchSink) add(index string, document []byte) error {
+	return s.batcher.add(bulkItem{index: index, document: append([]byte(nil), document...)})
+}
+
+// send 写入一批文档，返回 429 的文档单独重试，被拒绝的文档写入 dead letter 文件
+func (s *elasticsearchSink) send(items []bulkItem) batchResult[bulkItem] {
+	results, retryable, err := s.request(items)
+	if err != nil {
+		if retryable {
+			return batchResult[bulkItem]{retry: items, reason: err}
+		}
+		return batchResult[bulkItem]{err: multierr.Append(err, s.writeDeadLetter(items, nil, err.Error()))}
+	}
+
+	var retry []bulkItem
+	var rejected int
+	for i := range results {
+		switch status := results[i].Status; {
+		case status < 300:
+		case status == http.StatusTooManyRequests:
+			retry = append(retry, items[i])
+		default:
+			rejected++
+			err = multierr.Append(err, s.writeDeadLetter(items[i:i+1], &results[i], ""))
+		}
+	}
+	if rejected != 0 {
+		err = multierr.Append(fmt.Errorf("%d documents rejected", rejected), err)
+	}
+	result := batchResult[bulkItem]{err: err}
+	if len(retry) != 0 {
+		result.retry, result.reason = retry, fmt.Errorf("%d documents rejected with status 429", len(retry))
+	}
+	return result
+}
+
+// bulkResult _bulk 响应中单个文档的结果
+type bulkResult struct {
+	Status int             `json:"status"`
+	Error  json.RawMessage `json:"error,omitempty"`
+}
+
+// request 发送一次 _bulk 请求，返回每个文档的结果，请求失败时返回是否值得重试
+func (s *elasticsearchSink) request(items []bulkItem) ([]bulkResult, bool, error) {
+	var body bytes.Buffer
+	for _, item := range items {
+		action, _ := json.Marshal(map[string]any{"create": map[string]string{"_index": item.index}})
+		body.Write(action)
+		body.WriteByte('\n')
+		body.Write(item.document)
+		body.WriteByte('\n')
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, s.endpoint, &body)
+	if err != nil {
+		return nil, false, err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	if s.apiKey != "" {
+		req.Header.Set("Authorization", "ApiKey "+s.apiKey)
+	} else if s.username != "" {
+		req.SetBasicAuth(s.username, s.password)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, true, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, true, err
+	}
+	if resp.StatusCode/100 != 2 {
+		if len(data) > 1024 {
+			data = data[:1024]
+		}
+		err = fmt.Errorf("unexpected status %s: %s", resp.Status, bytes.TrimSpace(data))
+		return nil, resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode/100 == 5, err
+	}
+
+	var result struct {
+		Items []map[string]bulkResult `json:"items"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, false, fmt.Errorf("decode bulk response: %w", err)
+	}
+	if len(result.Items) != len(items) {
+		return nil, false, fmt.Errorf("bulk response has %d items, expect %d", len(result.Items), len(items))
+	}
+	results := make([]bulkResult, len(items))
+	for i, item := range result.Items {
+		for _, r := range item {
+			results[i] = r
+		}
+	}
+	return results, false, nil
+}
+
+// writeDeadLetter 每个文档写为一行 {"index","status","error","document"}，
+// result 为 nil 时表示整个请求失败，reason 为失败原因
+func (s *elasticsearchSink) writeDeadLetter(items []bulkItem, result *bulkResult, reason string) error {
+	if s.deadLetter == nil {
+		return nil
+	}
+	var buf bytes.Buffer
+	for _, item := range items {
+		record := struct {
+			Time     string          `json:"time"`
+			Index    string          `json:"index"`
+			Status   int             `json:"status,omitempty"`
+			Error    json.RawMessage `json:"error"`
+			Document json.RawMessage `json:"document"`
+		}{
+			Time:     time.Now().UTC().Format(time.RFC3339Nano),
+			Index:    item.index,
+			Document: item.document,
+		}
+		if result != nil {
+			record.Status, record.Error = result.Status, result.Error
+		}
+		if len(record.Error) == 0 {
+			record.Error, _ = json.Marshal(reason)
+		}
+		if !json.Valid(record.Document) {
+			record.Document, _ = json.Marshal(string(item.document))
+		}
+		data, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+	_, err := s.deadLetter.Write(buf.Bytes())
+	return err
+}
+
+// Sync 立即写入缓冲的文档，只尝试一次
+func (s *elasticsearchSink) Sync() error {
+	return s.batcher.push(false)
+}
+
+// Close 停止后台写入并写入剩余的文档，关闭时失败的请求不再重试
+func (s *elasticsearchSink) Close() error {
+	err := s.batcher.close()
+	if s.deadLetter != nil {
+		err = multierr.Append(err, s.deadLetter.Close())
+	}
+	return err
+}
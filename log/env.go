@@ -0,0 +1,139 @@
+package log
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// DefaultEnvPrefix FromEnv 未指定前缀时使用的环境变量前缀
+const DefaultEnvPrefix = "NOOP"
+
+// FromEnv 读取环境变量并叠加到当前配置上，prefix 为空时使用 DefaultEnvPrefix，
+// 无法解析的值会被忽略并由 Build 报告，支持的变量：
+//
+//	NOOP_LOG_LEVEL            日志级别，如 trace、debug、info、warn
+//	NOOP_LOG_STDOUT_LEVEL     stdout 的日志级别，覆盖 NOOP_LOG_LEVEL
+//	NOOP_LOG_FILE_LEVEL       日志文件的级别，覆盖 NOOP_LOG_LEVEL
+//	NOOP_LOG_CONSOLE          控制台输出，可选 stdout、stderr、none
+//	NOOP_LOG_FILE             日志文件名
+//	NOOP_LOG_WARN_FILE        开启 warn 日志文件，值为空时使用默认文件名
+//	NOOP_LOG_ERROR_FILE       开启 error 日志文件，值为空时使用默认文件名
+//	NOOP_LOG_MAX_SIZE         单个日志文件的最大尺寸，单位 MB
+//	NOOP_LOG_MAX_AGE          旧文件保留的天数
+//	NOOP_LOG_MAX_BACKUPS      旧文件保留的数量
+//	NOOP_LOG_COMPRESS         是否压缩旧文件，如 true、false
+//	NOOP_LOG_MAX_TOTAL_BYTES  所有日志文件的总大小上限，超出时删除最旧的文件
+//	NOOP_LOG_FIELDS           附加字段，格式为 k1=v1,k2=v2
+//	NOOP_LOG_HUMAN_TIME       人类可读时间的时区，如 Asia/Shanghai，值为空时使用本地时区
+//	NOOP_LOG_TIME_ROTATION    按时间切换文件的周期，如 daily、hourly、30m
+//	NOOP_LOG_TIME_ZONE        按时间切换文件时使用的时区，值为空时使用本地时区
+func (c *Config) FromEnv(prefix string) *Config {
+	if prefix == "" {
+		prefix = DefaultEnvPrefix
+	}
+	prefix = strings.TrimSuffix(prefix, "_") + "_LOG_"
+
+	if value, ok := os.LookupEnv(prefix + "LEVEL"); ok && value != "" {
+		if level, err := ParseLevel(value); err != nil {
+			c.addError(prefix+"LEVEL", err)
+		} else {
+			c.WithLevel(level)
+		}
+	}
+	if value, ok := os.LookupEnv(prefix + "STDOUT_LEVEL"); ok && value != "" {
+		if level, err := ParseLevel(value); err != nil {
+			c.addError(prefix+"STDOUT_LEVEL", err)
+		} else {
+			c.WithStdoutLevel(level)
+		}
+	}
+	if value, ok := os.LookupEnv(prefix + "FILE_LEVEL"); ok && value != "" {
+		if level, err := ParseLevel(value); err != nil {
+			c.addError(prefix+"FILE_LEVEL", err)
+		} else {
+			c.WithFileLevel(level)
+		}
+	}
+	if value, ok := os.LookupEnv(prefix + "CONSOLE"); ok {
+		if err := c.withConsole(value); err != nil {
+			c.addError(prefix+"CONSOLE", err)
+		}
+	}
+	if value, ok := os.LookupEnv(prefix + "FILE"); ok && value != "" {
+		c.WithFilename(value)
+	}
+	if value, ok := os.LookupEnv(prefix + "WARN_FILE"); ok {
+		c.WithWarnLog(value)
+	}
+	if value, ok := os.LookupEnv(prefix + "ERROR_FILE"); ok {
+		c.WithErrorLog(value)
+	}
+	policy := c.Rotation()
+	for name, target := range map[string]*int{
+		"MAX_SIZE":    &policy.MaxSize,
+		"MAX_AGE":     &policy.MaxAge,
+		"MAX_BACKUPS": &policy.MaxBackups,
+	} {
+		if value, ok := os.LookupEnv(prefix + name); ok && value != "" {
+			if n, err := strconv.Atoi(value); err != nil {
+				c.addError(prefix+name, err)
+			} else {
+				*target = n
+			}
+		}
+	}
+	if value, ok := os.LookupEnv(prefix + "COMPRESS"); ok && value != "" {
+		if compress, err := strconv.ParseBool(value); err != nil {
+			c.addError(prefix+"COMPRESS", err)
+		} else {
+			policy.Compress = compress
+		}
+	}
+	c.WithRotation(policy)
+	if value, ok := os.LookupEnv(prefix + "MAX_TOTAL_BYTES"); ok && value != "" {
+		if n, err := strconv.ParseInt(value, 10, 64); err != nil {
+			c.addError(prefix+"MAX_TOTAL_BYTES", err)
+		} else {
+			c.WithRetention(RetentionPolicy{MaxTotalBytes: n})
+		}
+	}
+	if value, ok := os.LookupEnv(prefix + "FIELDS"); ok {
+		c.WithFields(parseEnvFields(value))
+	}
+	if value, ok := os.LookupEnv(prefix + "HUMAN_TIME"); ok {
+		if location, err := loadLocation(value); err != nil {
+			c.addError(prefix+"HUMAN_TIME", err)
+		} else {
+			c.WithHumanTime(location)
+		}
+	}
+	if value, ok := os.LookupEnv(prefix + "TIME_ROTATION"); ok && value != "" {
+		period, err := parseRotationPeriod(value)
+		if err != nil {
+			c.addError(prefix+"TIME_ROTATION", err)
+		}
+		location, zoneErr := loadLocation(os.Getenv(prefix + "TIME_ZONE"))
+		if zoneErr != nil {
+			c.addError(prefix+"TIME_ZONE", zoneErr)
+		}
+		if err == nil && zoneErr == nil {
+			c.WithTimeRotation(period, location)
+		}
+	}
+	return c
+}
+
+// parseEnvFields 解析 k1=v1,k2=v2 格式的字段，忽略没有 key 的项
+func parseEnvFields(value string) map[string]any {
+	fields := make(map[string]any)
+	for _, pair := range strings.Split(value, ",") {
+		k, v, _ := strings.Cut(pair, "=")
+		k = strings.TrimSpace(k)
+		if k == "" {
+			continue
+		}
+		fields[k] = strings.TrimSpace(v)
+	}
+	return fields
+}
@@ -0,0 +1,98 @@
+package log
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConfig_FromEnv(t *testing.T) {
+	t.Setenv("NOOP_LOG_LEVEL", "warn")
+	t.Setenv("NOOP_LOG_FILE", "env.log")
+	t.Setenv("NOOP_LOG_WARN_FILE", "")
+	t.Setenv("NOOP_LOG_FIELDS", "service=order, version=2.1.0,,=ignored")
+	t.Setenv("NOOP_LOG_HUMAN_TIME", "UTC")
+
+	c := New().WithFilename("builder.log").WithLevel(DebugLevel).FromEnv("")
+
+	if c.stdoutConfig.level != WarnLevel || c.rollingConfig.level != WarnLevel {
+		t.Errorf("level = %v/%v, want warn", c.stdoutConfig.level, c.rollingConfig.level)
+	}
+	if c.rollingConfig.logger.Filename != "env.log" {
+		t.Errorf("filename = %s, want env.log", c.rollingConfig.logger.Filename)
+	}
+	if !c.levelFilterFileConfig.warnLevelEnable || c.levelFilterFileConfig.warnLogFilename != "" {
+		t.Errorf("warn log = %+v", c.levelFilterFileConfig)
+	}
+	if c.levelFilterFileConfig.errorLevelEnable {
+		t.Error("error log should stay disabled")
+	}
+	want := map[string]any{"service": "order", "version": "2.1.0", HumanTime: time.UTC}
+	if len(c.fieldsConfig.fields) != len(want) {
+		t.Errorf("fields = %v, want %v", c.fieldsConfig.fields, want)
+	}
+	for k, v := range want {
+		if c.fieldsConfig.fields[k] != v {
+			t.Errorf("fields[%s] = %v, want %v", k, c.fieldsConfig.fields[k], v)
+		}
+	}
+}
+
+func TestConfig_FromEnvPrefix(t *testing.T) {
+	t.Setenv("NOOP_LOG_LEVEL", "error")
+	t.Setenv("ORDER_LOG_LEVEL", "info")
+
+	c := New().FromEnv("ORDER_")
+	if c.rollingConfig.level != InfoLevel {
+		t.Errorf("level = %v, want info", c.rollingConfig.level)
+	}
+}
+
+func TestConfig_FromEnvKeepsBuilderValues(t *testing.T) {
+	t.Setenv("NOOP_LOG_LEVEL", "verbose")
+
+	c := New().WithFilename("builder.log").WithLevel(ErrorLevel).FromEnv("")
+	if c.rollingConfig.level != ErrorLevel {
+		t.Errorf("invalid level should be ignored, got %v", c.rollingConfig.level)
+	}
+	if c.rollingConfig.logger.Filename != "builder.log" {
+		t.Errorf("filename = %s, want builder.log", c.rollingConfig.logger.Filename)
+	}
+	if _, err := c.Build(); err == nil {
+		t.Error("Build() should report invalid NOOP_LOG_LEVEL")
+	}
+}
+
+func TestConfig_FromEnvSinkLevels(t *testing.T) {
+	t.Setenv("NOOP_LOG_LEVEL", "info")
+	t.Setenv("NOOP_LOG_STDOUT_LEVEL", "warn")
+	t.Setenv("NOOP_LOG_FILE_LEVEL", "debug")
+
+	c := New().FromEnv("")
+	if c.stdoutConfig.level != WarnLevel || c.rollingConfig.level != DebugLevel {
+		t.Errorf("levels = %v/%v, want warn/debug", c.stdoutConfig.level, c.rollingConfig.level)
+	}
+}
+
+func TestConfig_FromEnvConsole(t *testing.T) {
+	t.Setenv("NOOP_LOG_CONSOLE", "none")
+	if c := New().FromEnv(""); !c.stdoutConfig.disable {
+		t.Error("console should be disabled")
+	}
+
+	t.Setenv("NOOP_LOG_CONSOLE", "tty")
+	if _, err := New().FromEnv("").Build(); err == nil {
+		t.Error("Build() should report unknown console")
+	}
+}
+
+func TestConfig_FromEnvRotation(t *testing.T) {
+	t.Setenv("NOOP_LOG_MAX_SIZE", "50")
+	t.Setenv("NOOP_LOG_MAX_BACKUPS", "3")
+	t.Setenv("NOOP_LOG_COMPRESS", "true")
+
+	policy := New().FromEnv("").Rotation()
+	want := RotationPolicy{MaxSize: 50, MaxAge: 30, MaxBackups: 3, Compress: true}
+	if policy != want {
+		t.Errorf("policy = %+v, want %+v", policy, want)
+	}
+}
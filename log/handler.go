@@ -0,0 +1,191 @@
+package log
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// registry 记录所有设置了名称的日志器，同名日志器后 Init 的覆盖先 Init 的
+var registry = struct {
+	sync.RWMutex
+	loggers map[string]*Logger
+}{loggers: make(map[string]*Logger)}
+
+func registerLogger(name string, logger *Logger) {
+	if name == "" {
+		return
+	}
+	registry.Lock()
+	defer registry.Unlock()
+	registry.loggers[name] = logger
+}
+
+// unregisterLogger 只移除仍指向 logger 的注册，避免误删同名的新日志器
+func unregisterLogger(name string, logger *Logger) {
+	registry.Lock()
+	defer registry.Unlock()
+	if registry.loggers[name] == logger {
+		delete(registry.loggers, name)
+	}
+}
+
+func lookupLogger(name string) *Logger {
+	registry.RLock()
+	defer registry.RUnlock()
+	return registry.loggers[name]
+}
+
+func registeredLoggers() map[string]*Logger {
+	registry.RLock()
+	defer registry.RUnlock()
+	loggers := make(map[string]*Logger, len(registry.loggers))
+	for name, logger := range registry.loggers {
+		loggers[name] = logger
+	}
+	return loggers
+}
+
+// levelState 级别接口的请求与响应，PUT 时 level 同时作用于 stdout 和日志文件，
+// stdout、file 分别只作用于对应的输出，未出现的字段保持不变
+type levelState struct {
+	Level  string `json:"level,omitempty"`
+	Stdout string `json:"stdout,omitempty"`
+	File   string `json:"file,omitempty"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// LevelHandler 返回查看和调整当前日志器级别的 http.Handler，GET 返回当前级别，
+// PUT 接收 {"level": "debug"} 或 {"stdout": "warn", "file": "debug"} 调整级别
+func (l *Logger) LevelHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, l.levelState())
+		case http.MethodPut:
+			state, err := decodeLevelState(r)
+			if err != nil {
+				writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+				return
+			}
+			if err := l.applyLevelState(state); err != nil {
+				writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+				return
+			}
+			writeJSON(w, http.StatusOK, l.levelState())
+		default:
+			w.Header().Set("Allow", "GET, PUT")
+			writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "only GET and PUT are supported"})
+		}
+	})
+}
+
+// LevelsHandler 返回管理所有具名日志器级别的 http.Handler，GET 返回以名称为 key 的级别列表，
+// PUT 通过 ?name= 指定日志器，未指定时调整所有日志器，请求体与 LevelHandler 相同
+func LevelsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, registeredLevelStates())
+		case http.MethodPut:
+			state, err := decodeLevelState(r)
+			if err != nil {
+				writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+				return
+			}
+
+			loggers := registeredLoggers()
+			if name := r.URL.Query().Get("name"); name != "" {
+				logger := lookupLogger(name)
+				if logger == nil {
+					writeJSON(w, http.StatusNotFound, errorResponse{Error: fmt.Sprintf("logger %q not found", name)})
+					return
+				}
+				loggers = map[string]*Logger{name: logger}
+			}
+
+			names := make([]string, 0, len(loggers))
+			for name := range loggers {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				if err := loggers[name].applyLevelState(state); err != nil {
+					writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+					return
+				}
+			}
+			writeJSON(w, http.StatusOK, registeredLevelStates())
+		default:
+			w.Header().Set("Allow", "GET, PUT")
+			writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "only GET and PUT are supported"})
+		}
+	})
+}
+
+func registeredLevelStates() map[string]levelState {
+	states := make(map[string]levelState)
+	for name, logger := range registeredLoggers() {
+		states[name] = logger.levelState()
+	}
+	return states
+}
+
+func (l *Logger) levelState() levelState {
+	return levelState{
+		Stdout: l.StdoutLevel().String(),
+		File:   l.FileLevel().String(),
+	}
+}
+
+// applyLevelState 先解析全部级别再统一生效，避免请求部分成功
+func (l *Logger) applyLevelState(state levelState) error {
+	stdoutLevel, fileLevel := l.StdoutLevel(), l.FileLevel()
+	if state.Level != "" {
+		level, err := ParseLevel(state.Level)
+		if err != nil {
+			return err
+		}
+		stdoutLevel, fileLevel = level, level
+	}
+	if state.Stdout != "" {
+		level, err := ParseLevel(state.Stdout)
+		if err != nil {
+			return err
+		}
+		stdoutLevel = level
+	}
+	if state.File != "" {
+		level, err := ParseLevel(state.File)
+		if err != nil {
+			return err
+		}
+		fileLevel = level
+	}
+	l.SetStdoutLevel(stdoutLevel)
+	l.SetFileLevel(fileLevel)
+	return nil
+}
+
+func decodeLevelState(r *http.Request) (levelState, error) {
+	var state levelState
+	if err := json.NewDecoder(r.Body).Decode(&state); err != nil {
+		return state, fmt.Errorf("decode request body: %w", err)
+	}
+	if state.Level == "" && state.Stdout == "" && state.File == "" {
+		return state, errors.New("must specify level, stdout or file")
+	}
+	return state, nil
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,95 @@
+package log
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func doLevelRequest(t *testing.T, handler http.Handler, method, target, body string) (int, []byte) {
+	t.Helper()
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec.Code, rec.Body.Bytes()
+}
+
+func TestLogger_LevelHandler(t *testing.T) {
+	logger := New().WithFilename(filepath.Join(t.TempDir(), "handler.log")).WithLevel(InfoLevel).Init()
+	handler := logger.LevelHandler()
+
+	code, body := doLevelRequest(t, handler, http.MethodGet, "/", "")
+	var state levelState
+	if err := json.Unmarshal(body, &state); err != nil {
+		t.Fatal(err)
+	}
+	if code != http.StatusOK || state.Stdout != "info" || state.File != "info" {
+		t.Errorf("GET = %d %s", code, body)
+	}
+
+	code, body = doLevelRequest(t, handler, http.MethodPut, "/", `{"stdout": "warn", "file": "debug"}`)
+	if code != http.StatusOK {
+		t.Fatalf("PUT = %d %s", code, body)
+	}
+	if logger.StdoutLevel() != WarnLevel || logger.FileLevel() != DebugLevel {
+		t.Errorf("levels = %v/%v, want warn/debug", logger.StdoutLevel(), logger.FileLevel())
+	}
+	if logger.Level() != DebugLevel {
+		t.Errorf("Level() = %v, want debug", logger.Level())
+	}
+
+	code, _ = doLevelRequest(t, handler, http.MethodPut, "/", `{"level": "error"}`)
+	if code != http.StatusOK || logger.StdoutLevel() != ErrorLevel || logger.FileLevel() != ErrorLevel {
+		t.Errorf("PUT level = %d, levels = %v/%v", code, logger.StdoutLevel(), logger.FileLevel())
+	}
+
+	// 非法级别不应部分生效
+	code, _ = doLevelRequest(t, handler, http.MethodPut, "/", `{"stdout": "info", "file": "verbose"}`)
+	if code != http.StatusBadRequest || logger.StdoutLevel() != ErrorLevel {
+		t.Errorf("PUT invalid = %d, stdout = %v", code, logger.StdoutLevel())
+	}
+
+	if code, _ = doLevelRequest(t, handler, http.MethodPut, "/", `{}`); code != http.StatusBadRequest {
+		t.Errorf("PUT empty = %d, want 400", code)
+	}
+	if code, _ = doLevelRequest(t, handler, http.MethodPost, "/", ""); code != http.StatusMethodNotAllowed {
+		t.Errorf("POST = %d, want 405", code)
+	}
+}
+
+func TestLevelsHandler(t *testing.T) {
+	dir := t.TempDir()
+	orders := New().WithName("orders").WithFilename(filepath.Join(dir, "orders.log")).WithLevel(InfoLevel).Init()
+	users := New().WithName("users").WithFilename(filepath.Join(dir, "users.log")).WithLevel(InfoLevel).Init()
+	handler := LevelsHandler()
+
+	code, body := doLevelRequest(t, handler, http.MethodPut, "/?name=orders", `{"file": "debug"}`)
+	if code != http.StatusOK {
+		t.Fatalf("PUT = %d %s", code, body)
+	}
+	if orders.FileLevel() != DebugLevel || users.FileLevel() != InfoLevel {
+		t.Errorf("levels = %v/%v, want debug/info", orders.FileLevel(), users.FileLevel())
+	}
+
+	var states map[string]levelState
+	if err := json.Unmarshal(body, &states); err != nil {
+		t.Fatal(err)
+	}
+	if states["orders"].File != "debug" || states["users"].File != "info" {
+		t.Errorf("states = %v", states)
+	}
+
+	if code, _ = doLevelRequest(t, handler, http.MethodPut, "/", `{"level": "warn"}`); code != http.StatusOK {
+		t.Errorf("PUT all = %d", code)
+	}
+	if orders.Level() != WarnLevel || users.Level() != WarnLevel {
+		t.Errorf("levels = %v/%v, want warn", orders.Level(), users.Level())
+	}
+
+	if code, _ = doLevelRequest(t, handler, http.MethodPut, "/?name=missing", `{"level": "warn"}`); code != http.StatusNotFound {
+		t.Errorf("PUT missing = %d, want 404", code)
+	}
+}
@@ -0,0 +1,133 @@
+package log
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// DefaultJournaldSocket systemd-journald 接收原生协议的 socket
+const DefaultJournaldSocket = "/run/systemd/journal/socket"
+
+// WithJournald 通过 systemd-journald 原生协议写入 level 及以上的日志，仅支持 Linux。
+// WithFields 设置的字段与每条日志的字段都转换为大写的 journal 字段，PRIORITY 由级别映射，
+// CODE_FILE、CODE_LINE、CODE_FUNC 来自 caller。等价于 WithSink("journald://", level, "")，
+// 也可以通过 journald:///path/to/socket?identifier=name 指定 socket 和 SYSLOG_IDENTIFIER
+func (c *Config) WithJournald(level Level) *Config {
+	return c.WithSink("journald://", level, "")
+}
+
+// journaldOptions 从 URL 中解析 socket 路径与 SYSLOG_IDENTIFIER
+func journaldOptions(u *url.URL) (socket string, identifier string) {
+	socket = u.Path
+	if socket == "" {
+		socket = DefaultJournaldSocket
+	}
+	identifier = u.Query().Get("identifier")
+	if identifier == "" {
+		identifier = filepath.Base(os.Args[0])
+	}
+	return socket, identifier
+}
+
+// journaldEntry 按原生协议序列化一条日志，字段按名称排序，后出现的同名字段覆盖先出现的
+func journaldEntry(entry zapcore.Entry, identifier string, static map[string]any, fields []zapcore.Field) []byte {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, field := range fields {
+		field.AddTo(enc)
+	}
+
+	values := make(map[string]string, len(static)+len(enc.Fields)+6)
+	for name, value := range static {
+		journaldSetField(values, name, value)
+	}
+	for name, value := range enc.Fields {
+		journaldSetField(values, name, value)
+	}
+	values["MESSAGE"] = entry.Message
+	values["PRIORITY"] = strconv.Itoa(syslogSeverity(entry.Level))
+	values["SYSLOG_IDENTIFIER"] = identifier
+	if entry.LoggerName != "" {
+		values["LOGGER"] = entry.LoggerName
+	}
+	if entry.Caller.Defined {
+		values["CODE_FILE"] = entry.Caller.File
+		values["CODE_LINE"] = strconv.Itoa(entry.Caller.Line)
+		if entry.Caller.Function != "" {
+			values["CODE_FUNC"] = entry.Caller.Function
+		}
+	}
+	if entry.Stack != "" {
+		values["STACKTRACE"] = entry.Stack
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	for _, name := range names {
+		value := values[name]
+		if !strings.ContainsRune(value, '\n') {
+			buf.WriteString(name + "=" + value + "\n")
+			continue
+		}
+		// 含换行的值使用 名称\n + 64 位小端长度 + 值 + \n 的形式
+		buf.WriteString(name + "\n")
+		_ = binary.Write(&buf, binary.LittleEndian, uint64(len(value)))
+		buf.WriteString(value + "\n")
+	}
+	return buf.Bytes()
+}
+
+func journaldSetField(values map[string]string, name string, value any) {
+	name = journaldFieldName(name)
+	if name == "" {
+		return
+	}
+	switch v := value.(type) {
+	case string:
+		values[name] = v
+	case fmt.Stringer:
+		values[name] = v.String()
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, uintptr, float32, float64:
+		values[name] = fmt.Sprint(v)
+	default:
+		if data, err := json.Marshal(v); err == nil {
+			values[name] = string(data)
+		} else {
+			values[name] = fmt.Sprint(v)
+		}
+	}
+}
+
+// journaldFieldName journal 字段名只能由大写字母、数字和下划线组成，不能以数字开头，
+// 以下划线开头的字段由 journald 保留，最长 64 个字符
+func journaldFieldName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+	name = strings.TrimLeft(name, "_0123456789")
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	return name
+}
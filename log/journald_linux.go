@@ -0,0 +1,98 @@
+//go:build linux
+
+package log
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"syscall"
+
+	"go.uber.org/zap/zapcore"
+	"golang.org/x/sys/unix"
+)
+
+func init() {
+	if err := RegisterSink("journald", newJournaldSink); err != nil {
+		panic(err)
+	}
+}
+
+func newJournaldSink(u *url.URL) (Sink, error) {
+	socket, identifier := journaldOptions(u)
+	// 未绑定地址的数据报 socket，每条日志通过 WriteMsgUnix 发往 journald
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Net: "unixgram"})
+	if err != nil {
+		return nil, err
+	}
+	return &journaldSink{
+		conn:       conn,
+		addr:       &net.UnixAddr{Name: socket, Net: "unixgram"},
+		identifier: identifier,
+	}, nil
+}
+
+// journaldSink 超过数据报大小限制的日志写入 memfd，再将文件描述符发送给 journald
+type journaldSink struct {
+	conn       *net.UnixConn
+	addr       *net.UnixAddr
+	identifier string
+}
+
+func (s *journaldSink) Core(_ zapcore.Encoder, level zapcore.LevelEnabler, fields map[string]any) zapcore.Core {
+	return &entryCore{
+		LevelEnabler: level,
+		write: func(entry zapcore.Entry, entryFields []zapcore.Field) error {
+			return s.send(journaldEntry(entry, s.identifier, fields, entryFields))
+		},
+	}
+}
+
+// Write 将 p 作为 MESSAGE 以 info 级别发送
+func (s *journaldSink) Write(p []byte) (int, error) {
+	entry := zapcore.Entry{Level: zapcore.InfoLevel, Message: string(p)}
+	if err := s.send(journaldEntry(entry, s.identifier, nil, nil)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (s *journaldSink) send(data []byte) error {
+	_, _, err := s.conn.WriteMsgUnix(data, nil, s.addr)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, syscall.EMSGSIZE) && !errors.Is(err, syscall.ENOBUFS) {
+		return err
+	}
+	return s.sendMemfd(data)
+}
+
+// sendMemfd 将数据写入密封的 memfd 并通过 SCM_RIGHTS 发送，journald 从文件中读取整条日志
+func (s *journaldSink) sendMemfd(data []byte) error {
+	fd, err := unix.MemfdCreate("noop-journal", unix.MFD_CLOEXEC|unix.MFD_ALLOW_SEALING)
+	if err != nil {
+		return fmt.Errorf("create memfd: %w", err)
+	}
+	file := os.NewFile(uintptr(fd), "noop-journal")
+	defer file.Close()
+
+	if _, err := file.Write(data); err != nil {
+		return err
+	}
+	if _, err := unix.FcntlInt(file.Fd(), unix.F_ADD_SEALS, unix.F_SEAL_SHRINK|unix.F_SEAL_GROW|unix.F_SEAL_WRITE|unix.F_SEAL_SEAL); err != nil {
+		return fmt.Errorf("seal memfd: %w", err)
+	}
+	_, _, err = s.conn.WriteMsgUnix(nil, unix.UnixRights(int(file.Fd())), s.addr)
+	return err
+}
+
+func (s *journaldSink) Sync() error {
+	return nil
+}
+
+func (s *journaldSink) Close() error {
+	return s.conn.Close()
+}
@@ -0,0 +1,140 @@
+//go:build linux
+
+package log
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/sys/unix"
+)
+
+// parseJournaldEntry 解析原生协议的数据
+func parseJournaldEntry(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	fields := make(map[string]string)
+	for len(data) > 0 {
+		newline := bytes.IndexByte(data, '\n')
+		if newline < 0 {
+			t.Fatalf("truncated entry %q", data)
+		}
+		line := string(data[:newline])
+		data = data[newline+1:]
+		if name, value, ok := strings.Cut(line, "="); ok {
+			fields[name] = value
+			continue
+		}
+		size := binary.LittleEndian.Uint64(data[:8])
+		fields[line] = string(data[8 : 8+size])
+		data = data[8+size+1:]
+	}
+	return fields
+}
+
+// receiveJournald 从 stand-in socket 读取一条日志，数据在 memfd 中时从文件描述符读取
+func receiveJournald(t *testing.T, conn *net.UnixConn) map[string]string {
+	t.Helper()
+	buf := make([]byte, 1<<20)
+	oob := make([]byte, unix.CmsgSpace(4))
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, oobn, _, _, err := conn.ReadMsgUnix(buf, oob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if oobn == 0 {
+		return parseJournaldEntry(t, buf[:n])
+	}
+
+	messages, err := unix.ParseSocketControlMessage(oob[:oobn])
+	if err != nil {
+		t.Fatal(err)
+	}
+	fds, err := unix.ParseUnixRights(&messages[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := os.NewFile(uintptr(fds[0]), "memfd")
+	defer file.Close()
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parseJournaldEntry(t, data)
+}
+
+func TestJournaldSink(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "journal.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	// 缩小接收缓冲区，使大日志超过数据报大小限制
+	_ = conn.SetReadBuffer(64 * 1024)
+
+	logger, err := New().
+		WithFilename(filepath.Join(t.TempDir(), "app.log")).
+		WithoutStdout().
+		WithFields(map[string]any{"service": "api", "build-info": map[string]any{"version": "1.0"}}).
+		WithSink("journald://"+socket+"?identifier=noop-test", InfoLevel, "").
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer logger.Close()
+
+	logger.Debug("filtered")
+	logger.Named("http").Warn("multi\nline", zap.String("user_id", "u-1"), zap.Int("_attempt", 3))
+	fields := receiveJournald(t, conn)
+	for name, want := range map[string]string{
+		"MESSAGE":           "multi\nline",
+		"PRIORITY":          "4",
+		"SYSLOG_IDENTIFIER": "noop-test",
+		"LOGGER":            "http",
+		"SERVICE":           "api",
+		"BUILD_INFO":        `{"version":"1.0"}`,
+		"USER_ID":           "u-1",
+		"ATTEMPT":           "3",
+		"CODE_FUNC":         "github.com/xops-infra/noop/log.TestJournaldSink",
+	} {
+		if fields[name] != want {
+			t.Errorf("%s = %q, want %q", name, fields[name], want)
+		}
+	}
+	if !strings.HasSuffix(fields["CODE_FILE"], "journald_linux_test.go") || fields["CODE_LINE"] == "" {
+		t.Errorf("caller fields = %s:%s", fields["CODE_FILE"], fields["CODE_LINE"])
+	}
+
+	large := strings.Repeat("x", 512*1024)
+	logger.Error(large)
+	if fields := receiveJournald(t, conn); fields["MESSAGE"] != large || fields["PRIORITY"] != "3" {
+		t.Errorf("large entry was not delivered through memfd, got %d bytes", len(fields["MESSAGE"]))
+	}
+}
+
+func TestJournaldFieldName(t *testing.T) {
+	tests := map[string]string{
+		"user_id":   "USER_ID",
+		"_private":  "PRIVATE",
+		"2fa":       "FA",
+		"trace.id":  "TRACE_ID",
+		"__":        "",
+		"MixedCase": "MIXEDCASE",
+	}
+	for name, want := range tests {
+		if got := journaldFieldName(name); got != want {
+			t.Errorf("journaldFieldName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -20,13 +20,26 @@
 
 package log
 
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap/zapcore"
+)
+
+var errUnmarshalNilLevel = errors.New("can't unmarshal a nil *Level")
+
 // A Level is a logging priority. Higher levels are more important.
 type Level int8
 
 const (
+	// TraceLevel logs are finer-grained than Debug, such as wire-level dumps,
+	// and are usually only enabled while chasing a specific problem.
+	TraceLevel Level = iota - 2
 	// DebugLevel logs are typically voluminous, and are usually disabled in
 	// production.
-	DebugLevel Level = iota - 1
+	DebugLevel
 	// InfoLevel is the default logging priority.
 	InfoLevel
 	// WarnLevel logs are more important than Info, but don't need individual
@@ -43,7 +56,7 @@ const (
 	// FatalLevel logs a message, then calls os.Exit(1).
 	FatalLevel
 
-	_minLevel = DebugLevel
+	_minLevel = TraceLevel
 	_maxLevel = FatalLevel
 
 	// InvalidLevel is an invalid value for Level.
@@ -51,3 +64,118 @@ const (
 	// Core implementations may panic if they see messages of this level.
 	InvalidLevel = _maxLevel + 1
 )
+
+// ParseLevel parses a level based on the lower-case or all-caps ASCII
+// representation of the log level. Common aliases such as "warning" and "err"
+// are accepted too. If the provided ASCII representation is invalid an error
+// is returned.
+//
+// This is particularly useful when dealing with text input to configure log
+// levels.
+func ParseLevel(text string) (Level, error) {
+	var level Level
+	err := level.UnmarshalText([]byte(text))
+	return level, err
+}
+
+// String returns a lower-case ASCII representation of the log level.
+func (l Level) String() string {
+	switch l {
+	case TraceLevel:
+		return "trace"
+	case DebugLevel:
+		return "debug"
+	case InfoLevel:
+		return "info"
+	case WarnLevel:
+		return "warn"
+	case ErrorLevel:
+		return "error"
+	case DPanicLevel:
+		return "dpanic"
+	case PanicLevel:
+		return "panic"
+	case FatalLevel:
+		return "fatal"
+	default:
+		return fmt.Sprintf("Level(%d)", l)
+	}
+}
+
+// MarshalText marshals the Level to text. Note that the text representation
+// drops the -Level suffix.
+func (l Level) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+// UnmarshalText unmarshals text to a level. Like MarshalText, UnmarshalText
+// expects the text representation of a Level to drop the -Level suffix.
+//
+// In particular, this makes it easy to configure logging levels using YAML,
+// TOML, or JSON files.
+func (l *Level) UnmarshalText(text []byte) error {
+	if l == nil {
+		return errUnmarshalNilLevel
+	}
+	if !l.unmarshalText(text) && !l.unmarshalText(bytes.ToLower(text)) {
+		return fmt.Errorf("unrecognized level: %q", text)
+	}
+	return nil
+}
+
+func (l *Level) unmarshalText(text []byte) bool {
+	switch string(text) {
+	case "trace", "TRACE":
+		*l = TraceLevel
+	case "debug", "DEBUG":
+		*l = DebugLevel
+	case "info", "INFO", "": // make the zero value useful
+		*l = InfoLevel
+	case "warn", "WARN", "warning", "WARNING":
+		*l = WarnLevel
+	case "error", "ERROR", "err", "ERR":
+		*l = ErrorLevel
+	case "dpanic", "DPANIC":
+		*l = DPanicLevel
+	case "panic", "PANIC":
+		*l = PanicLevel
+	case "fatal", "FATAL":
+		*l = FatalLevel
+	default:
+		return false
+	}
+	return true
+}
+
+// Set sets the level for the flag.Value interface.
+func (l *Level) Set(s string) error {
+	return l.UnmarshalText([]byte(s))
+}
+
+// Get gets the level for the flag.Getter interface.
+func (l *Level) Get() interface{} {
+	return *l
+}
+
+// zapcore 没有 trace 级别，以下编码器在 zapcore 对应编码器的基础上补充 trace 的输出
+
+// capitalColorLevelEncoder trace 使用青色，与 zapcore 中 debug 的品红色区分
+func capitalColorLevelEncoder(l zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
+	if Level(l) == TraceLevel {
+		enc.AppendString("\x1b[36mTRACE\x1b[0m")
+		return
+	}
+	zapcore.CapitalColorLevelEncoder(l, enc)
+}
+
+func capitalLevelEncoder(l zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
+	if Level(l) == TraceLevel {
+		enc.AppendString("TRACE")
+		return
+	}
+	zapcore.CapitalLevelEncoder(l, enc)
+}
+
+func lowercaseLevelEncoder(l zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
+	enc.AppendString(Level(l).String())
+}
@@ -0,0 +1,115 @@
+package log
+
+import (
+	"encoding/json"
+	"flag"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestLevelText(t *testing.T) {
+	for _, level := range []Level{TraceLevel, DebugLevel, InfoLevel, WarnLevel, ErrorLevel, DPanicLevel, PanicLevel, FatalLevel} {
+		text, err := level.MarshalText()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got Level
+		if err := got.UnmarshalText(text); err != nil {
+			t.Errorf("UnmarshalText(%s) error = %v", text, err)
+		}
+		if got != level {
+			t.Errorf("round trip of %v got %v", level, got)
+		}
+	}
+
+	if got := Level(42).String(); got != "Level(42)" {
+		t.Errorf("String() = %s, want Level(42)", got)
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := map[string]Level{
+		"trace":   TraceLevel,
+		"DEBUG":   DebugLevel,
+		"Info":    InfoLevel,
+		"":        InfoLevel,
+		"warning": WarnLevel,
+		"WARN":    WarnLevel,
+		"err":     ErrorLevel,
+		"Fatal":   FatalLevel,
+	}
+	for text, want := range tests {
+		got, err := ParseLevel(text)
+		if err != nil {
+			t.Errorf("ParseLevel(%q) error = %v", text, err)
+		}
+		if got != want {
+			t.Errorf("ParseLevel(%q) = %v, want %v", text, got, want)
+		}
+	}
+
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Error("ParseLevel(verbose) should fail")
+	}
+	var nilLevel *Level
+	if err := nilLevel.UnmarshalText([]byte("info")); err == nil {
+		t.Error("UnmarshalText on nil *Level should fail")
+	}
+}
+
+func TestLevelFlag(t *testing.T) {
+	level := InfoLevel
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Var(&level, "level", "log level")
+
+	if err := fs.Parse([]string{"-level", "Warning"}); err != nil {
+		t.Fatal(err)
+	}
+	if level != WarnLevel {
+		t.Errorf("level = %v, want warn", level)
+	}
+	if got := fs.Lookup("level").Value.(flag.Getter).Get(); got != WarnLevel {
+		t.Errorf("Get() = %v, want warn", got)
+	}
+	if err := fs.Parse([]string{"-level", "loud"}); err == nil {
+		t.Error("Parse should fail for unknown level")
+	}
+}
+
+func TestLevelJSON(t *testing.T) {
+	var doc struct {
+		Level Level `json:"level"`
+	}
+	if err := json.Unmarshal([]byte(`{"level": "trace"}`), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Level != TraceLevel {
+		t.Errorf("level = %v, want trace", doc.Level)
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"level":"trace"}` {
+		t.Errorf("Marshal = %s", data)
+	}
+}
+
+func TestCapitalColorLevelEncoder(t *testing.T) {
+	encode := func(level Level) string {
+		enc := zapcore.NewMapObjectEncoder()
+		_ = enc.AddArray("level", zapcore.ArrayMarshalerFunc(func(arr zapcore.ArrayEncoder) error {
+			capitalColorLevelEncoder(zapcore.Level(level), arr)
+			return nil
+		}))
+		return enc.Fields["level"].([]any)[0].(string)
+	}
+	trace, debug := encode(TraceLevel), encode(DebugLevel)
+	if trace != "\x1b[36mTRACE\x1b[0m" {
+		t.Errorf("trace = %q", trace)
+	}
+	if trace[:5] == debug[:5] {
+		t.Errorf("trace %q should use a different color from debug %q", trace, debug)
+	}
+}
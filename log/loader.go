@@ -0,0 +1,222 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// configDocument 声明式配置文档，字段与 Config 的 With* 方法一一对应，
+// 未出现的字段保持原有配置不变
+type configDocument struct {
+	Name         *string               `json:"name" yaml:"name"`
+	Level        *Level                `json:"level" yaml:"level"`
+	StdoutLevel  *Level                `json:"stdout_level" yaml:"stdout_level"`
+	FileLevel    *Level                `json:"file_level" yaml:"file_level"`
+	Console      *string               `json:"console" yaml:"console"`
+	Filename     *string               `json:"filename" yaml:"filename"`
+	MaxSize      *int                  `json:"max_size" yaml:"max_size"`
+	MaxAge       *int                  `json:"max_age" yaml:"max_age"`
+	MaxBackups   *int                  `json:"max_backups" yaml:"max_backups"`
+	Compress     *bool                 `json:"compress" yaml:"compress"`
+	LocalTime    *bool                 `json:"local_time" yaml:"local_time"`
+	Fields       map[string]any        `json:"fields" yaml:"fields"`
+	HumanTime    *string               `json:"human_time" yaml:"human_time"`
+	TimeRotation *timeRotationDocument `json:"time_rotation" yaml:"time_rotation"`
+	Retention    *retentionDocument    `json:"retention" yaml:"retention"`
+	WarnLog      *levelFilterDocument  `json:"warn_log" yaml:"warn_log"`
+	ErrorLog     *levelFilterDocument  `json:"error_log" yaml:"error_log"`
+	Sinks        []sinkDocument        `json:"sinks" yaml:"sinks"`
+}
+
+type timeRotationDocument struct {
+	Period   string `json:"period" yaml:"period"`
+	TimeZone string `json:"time_zone" yaml:"time_zone"`
+}
+
+type retentionDocument struct {
+	MaxTotalBytes int64  `json:"max_total_bytes" yaml:"max_total_bytes"`
+	Interval      string `json:"interval" yaml:"interval"`
+}
+
+// sinkDocument 对应 WithSink，未设置 level 时为 info
+type sinkDocument struct {
+	URL      string `json:"url" yaml:"url"`
+	Level    Level  `json:"level" yaml:"level"`
+	Encoding string `json:"encoding" yaml:"encoding"`
+}
+
+type levelFilterDocument struct {
+	Enable   bool   `json:"enable" yaml:"enable"`
+	Filename string `json:"filename" yaml:"filename"`
+	Level    *Level `json:"level" yaml:"level"`
+}
+
+// LoadConfig 从 YAML 或 JSON 文件加载配置，以 .json 结尾的文件按 JSON 解析，其余按 YAML 解析
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("load log config: %w", err)
+	}
+
+	c := New()
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(data, c)
+	default:
+		err = yaml.Unmarshal(data, c)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load log config %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// UnmarshalJSON 实现 json.Unmarshaler，将 JSON 文档叠加到当前配置上
+func (c *Config) UnmarshalJSON(data []byte) error {
+	var doc configDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	return c.apply(&doc)
+}
+
+// UnmarshalYAML 实现 yaml.Unmarshaler，将 YAML 文档叠加到当前配置上
+func (c *Config) UnmarshalYAML(value *yaml.Node) error {
+	var doc configDocument
+	if err := value.Decode(&doc); err != nil {
+		return err
+	}
+	return c.apply(&doc)
+}
+
+func (c *Config) apply(doc *configDocument) error {
+	if c.stdoutConfig == nil {
+		*c = *New()
+	}
+
+	if doc.Name != nil {
+		c.WithName(*doc.Name)
+	}
+	if doc.Level != nil {
+		c.WithLevel(*doc.Level)
+	}
+	if doc.StdoutLevel != nil {
+		c.WithStdoutLevel(*doc.StdoutLevel)
+	}
+	if doc.FileLevel != nil {
+		c.WithFileLevel(*doc.FileLevel)
+	}
+	if doc.Console != nil {
+		if err := c.withConsole(*doc.Console); err != nil {
+			return fmt.Errorf("console: %w", err)
+		}
+	}
+	if doc.Filename != nil {
+		c.WithFilename(*doc.Filename)
+	}
+	policy := c.Rotation()
+	if doc.MaxSize != nil {
+		policy.MaxSize = *doc.MaxSize
+	}
+	if doc.MaxAge != nil {
+		policy.MaxAge = *doc.MaxAge
+	}
+	if doc.MaxBackups != nil {
+		policy.MaxBackups = *doc.MaxBackups
+	}
+	if doc.Compress != nil {
+		policy.Compress = *doc.Compress
+	}
+	if doc.LocalTime != nil {
+		policy.LocalTime = *doc.LocalTime
+	}
+	c.WithRotation(policy)
+	if doc.Fields != nil {
+		c.WithFields(doc.Fields)
+	}
+	if doc.HumanTime != nil {
+		location, err := loadLocation(*doc.HumanTime)
+		if err != nil {
+			return fmt.Errorf("human_time: %w", err)
+		}
+		c.WithHumanTime(location)
+	}
+	if doc.TimeRotation != nil {
+		period, err := parseRotationPeriod(doc.TimeRotation.Period)
+		if err != nil {
+			return fmt.Errorf("time_rotation.period: %w", err)
+		}
+		location, err := loadLocation(doc.TimeRotation.TimeZone)
+		if err != nil {
+			return fmt.Errorf("time_rotation.time_zone: %w", err)
+		}
+		c.WithTimeRotation(period, location)
+	}
+	if doc.Retention != nil {
+		policy := RetentionPolicy{MaxTotalBytes: doc.Retention.MaxTotalBytes}
+		if doc.Retention.Interval != "" {
+			interval, err := time.ParseDuration(doc.Retention.Interval)
+			if err != nil {
+				return fmt.Errorf("retention.interval: %w", err)
+			}
+			policy.Interval = interval
+		}
+		c.WithRetention(policy)
+	}
+	if doc.WarnLog != nil && doc.WarnLog.Enable {
+		c.WithWarnLog(doc.WarnLog.Filename)
+		if doc.WarnLog.Level != nil {
+			c.WithWarnLogLevel(*doc.WarnLog.Level)
+		}
+	}
+	if doc.ErrorLog != nil && doc.ErrorLog.Enable {
+		c.WithErrorLog(doc.ErrorLog.Filename)
+		if doc.ErrorLog.Level != nil {
+			c.WithErrorLogLevel(*doc.ErrorLog.Level)
+		}
+	}
+	for _, sink := range doc.Sinks {
+		c.WithSink(sink.URL, sink.Level, sink.Encoding)
+	}
+	return nil
+}
+
+// withConsole 按名称设置控制台输出，支持 stdout、stderr 和 none
+func (c *Config) withConsole(name string) error {
+	switch strings.ToLower(name) {
+	case "stdout", "":
+		c.WithConsoleWriter(os.Stdout)
+	case "stderr":
+		c.WithStderr()
+	case "none":
+		c.WithoutStdout()
+	default:
+		return fmt.Errorf("unknown console %q, expect stdout, stderr or none", name)
+	}
+	return nil
+}
+
+// parseRotationPeriod 支持 daily、hourly 以及 time.ParseDuration 可以解析的周期
+func parseRotationPeriod(text string) (time.Duration, error) {
+	switch strings.ToLower(text) {
+	case "daily":
+		return RotateDaily, nil
+	case "hourly":
+		return RotateHourly, nil
+	}
+	return time.ParseDuration(text)
+}
+
+// loadLocation 空字符串表示本地时区，与 WithHumanTime(nil) 保持一致
+func loadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(name)
+}
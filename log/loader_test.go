@@ -0,0 +1,148 @@
+package log
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadConfig_YAML(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "log.yaml")
+	content := `
+level: info
+filename: ` + filepath.Join(dir, "app.log") + `
+max_size: 100
+max_age: 7
+fields:
+  service: user-service
+human_time: UTC
+warn_log:
+  enable: true
+  filename: ` + filepath.Join(dir, "warn.log") + `
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if c.stdoutConfig.level != InfoLevel || c.rollingConfig.level != InfoLevel {
+		t.Errorf("level = %v/%v, want info", c.stdoutConfig.level, c.rollingConfig.level)
+	}
+	if c.rollingConfig.logger.Filename != filepath.Join(dir, "app.log") {
+		t.Errorf("filename = %s", c.rollingConfig.logger.Filename)
+	}
+	if c.rollingConfig.logger.MaxSize != 100 || c.rollingConfig.logger.MaxAge != 7 {
+		t.Errorf("max_size/max_age = %d/%d", c.rollingConfig.logger.MaxSize, c.rollingConfig.logger.MaxAge)
+	}
+	if c.fieldsConfig.fields["service"] != "user-service" {
+		t.Errorf("fields = %v", c.fieldsConfig.fields)
+	}
+	if c.fieldsConfig.fields[HumanTime] != time.UTC {
+		t.Errorf("human time = %v", c.fieldsConfig.fields[HumanTime])
+	}
+	if !c.levelFilterFileConfig.warnLevelEnable || c.levelFilterFileConfig.warnLogFilename != filepath.Join(dir, "warn.log") {
+		t.Errorf("warn log = %+v", c.levelFilterFileConfig)
+	}
+	if c.levelFilterFileConfig.errorLevelEnable {
+		t.Error("error log should stay disabled")
+	}
+
+	logger := c.Init()
+	logger.Warn("loaded from yaml")
+	if _, err := os.Stat(filepath.Join(dir, "warn.log")); err != nil {
+		t.Errorf("warn log should be created: %v", err)
+	}
+}
+
+func TestLoadConfig_JSON(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "log.json")
+	content := `{"level": "error", "fields": {"version": "1.0.0"}, "error_log": {"enable": true}}`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if c.rollingConfig.level != ErrorLevel {
+		t.Errorf("level = %v, want error", c.rollingConfig.level)
+	}
+	if c.fieldsConfig.fields["version"] != "1.0.0" {
+		t.Errorf("fields = %v", c.fieldsConfig.fields)
+	}
+	if !c.levelFilterFileConfig.errorLevelEnable || c.levelFilterFileConfig.errorLogFilename != "" {
+		t.Errorf("error log = %+v", c.levelFilterFileConfig)
+	}
+}
+
+func TestLoadConfig_Errors(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := LoadConfig(filepath.Join(dir, "missing.yaml")); err == nil {
+		t.Error("LoadConfig() should fail for missing file")
+	}
+
+	badLevel := filepath.Join(dir, "bad.yaml")
+	if err := os.WriteFile(badLevel, []byte("level: verbose\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfig(badLevel); err == nil {
+		t.Error("LoadConfig() should fail for unknown level")
+	}
+
+	badLocation := filepath.Join(dir, "bad.json")
+	if err := os.WriteFile(badLocation, []byte(`{"human_time": "Mars/Olympus"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfig(badLocation); err == nil {
+		t.Error("LoadConfig() should fail for unknown time zone")
+	}
+}
+
+func TestConfig_UnmarshalJSONOverlay(t *testing.T) {
+	c := New().WithFilename("overlay.log").WithLevel(WarnLevel)
+	if err := json.Unmarshal([]byte(`{"level": "debug"}`), c); err != nil {
+		t.Fatal(err)
+	}
+	if c.rollingConfig.level != DebugLevel {
+		t.Errorf("level = %v, want debug", c.rollingConfig.level)
+	}
+	if c.rollingConfig.logger.Filename != "overlay.log" {
+		t.Errorf("filename should be kept, got %s", c.rollingConfig.logger.Filename)
+	}
+}
+
+func TestLoadConfig_Sinks(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "log.yaml")
+	content := `
+sinks:
+  - url: stderr://
+    level: warn
+    encoding: console
+  - url: udp://127.0.0.1:5140
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.sinks) != 2 {
+		t.Fatalf("sinks = %+v", c.sinks)
+	}
+	if c.sinks[0].level != WarnLevel || c.sinks[0].encoding != EncodingConsole || c.sinks[0].url.Scheme != "stderr" {
+		t.Errorf("first sink = %+v", c.sinks[0])
+	}
+	if c.sinks[1].level != InfoLevel || c.sinks[1].encoding != EncodingJSON || c.sinks[1].url.Host != "127.0.0.1:5140" {
+		t.Errorf("second sink = %+v", c.sinks[1])
+	}
+}
@@ -1,7 +1,10 @@
 package log
 
 import (
+	"bytes"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -226,3 +229,322 @@ func BenchmarkMultipleLoggers(b *testing.B) {
 		}
 	})
 }
+
+func TestLoggerSetLevel(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "set_level.log")
+	warnFilename := filepath.Join(dir, "set_level_warn.log")
+	logger := New().WithFilename(filename).WithLevel(InfoLevel).WithWarnLog(warnFilename).Init()
+
+	if logger.Level() != InfoLevel {
+		t.Errorf("Level() = %v, want info", logger.Level())
+	}
+	logger.Debug("hidden debug message")
+
+	logger.SetLevel(DebugLevel)
+	if logger.Level() != DebugLevel {
+		t.Errorf("Level() = %v, want debug", logger.Level())
+	}
+	logger.Debug("visible debug message")
+
+	logger.SetLevel(ErrorLevel)
+	logger.Info("hidden info message")
+	logger.Warn("hidden warn message")
+
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(content), "hidden") {
+		t.Errorf("messages below level should be dropped, got %s", content)
+	}
+	if !strings.Contains(string(content), "visible debug message") {
+		t.Errorf("debug message should be written after SetLevel, got %s", content)
+	}
+	if content, _ := os.ReadFile(warnFilename); strings.Contains(string(content), "hidden") {
+		t.Errorf("warn file should follow the level too, got %s", content)
+	}
+}
+
+func TestLoggerTrace(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "trace.log")
+	logger := New().WithFilename(filename).WithLevel(DebugLevel).Init()
+
+	logger.Trace("hidden trace message")
+	logger.Tracef("hidden trace %s", "format")
+	logger.SetLevel(TraceLevel)
+	logger.Trace("wire dump", zap.String("payload", "0xcafe"))
+	logger.Tracef("wire dump %d", 2)
+	logger.Tracef("wire dump 100%")
+
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(content), "hidden") {
+		t.Errorf("trace should be filtered at debug level, got %s", content)
+	}
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("want 3 trace lines, got %s", content)
+	}
+	if !strings.Contains(lines[2], `"msg":"wire dump 100%"`) {
+		t.Errorf("Tracef without args should not format, got %s", lines[2])
+	}
+	for _, line := range lines {
+		if !strings.Contains(line, `"level":"trace"`) || !strings.Contains(line, "logger_test.go") {
+			t.Errorf("unexpected trace line %s", line)
+		}
+	}
+}
+
+func TestLoggerIndependentLevels(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "independent.log")
+	warnFilename := filepath.Join(dir, "independent_warn.log")
+	logger := New().
+		WithFilename(filename).
+		WithStdoutLevel(WarnLevel).
+		WithFileLevel(DebugLevel).
+		WithWarnLog(warnFilename).
+		WithWarnLogLevel(WarnLevel).
+		Init()
+
+	if logger.StdoutLevel() != WarnLevel || logger.FileLevel() != DebugLevel {
+		t.Errorf("levels = %v/%v, want warn/debug", logger.StdoutLevel(), logger.FileLevel())
+	}
+	logger.Debug("debug kept in file")
+
+	// warn 日志文件设置了独立级别，不受 SetFileLevel 影响
+	logger.SetFileLevel(ErrorLevel)
+	logger.Warn("warn kept in warn file")
+
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), "debug kept in file") {
+		t.Errorf("debug message should be written to file, got %s", content)
+	}
+	content, err = os.ReadFile(warnFilename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), "warn kept in warn file") {
+		t.Errorf("warn message should be written to warn file, got %s", content)
+	}
+}
+
+func TestLoggerConsoleWriter(t *testing.T) {
+	var console bytes.Buffer
+	logger := New().
+		WithFilename(filepath.Join(t.TempDir(), "console.log")).
+		WithConsoleWriter(&console).
+		Init()
+	logger.Info("to console writer")
+
+	if !strings.Contains(console.String(), "INFO") || !strings.Contains(console.String(), "to console writer") {
+		t.Errorf("console output = %q", console.String())
+	}
+	// 非终端输出不应包含颜色转义字符
+	if strings.Contains(console.String(), "\x1b[") {
+		t.Errorf("console output should not be colored, got %q", console.String())
+	}
+}
+
+func TestLoggerWithoutStdout(t *testing.T) {
+	var console bytes.Buffer
+	filename := filepath.Join(t.TempDir(), "without_stdout.log")
+	logger := New().
+		WithFilename(filename).
+		WithConsoleWriter(&console).
+		WithoutStdout().
+		Init()
+	logger.Info("file only")
+
+	if console.Len() != 0 {
+		t.Errorf("console should be disabled, got %q", console.String())
+	}
+	if content, _ := os.ReadFile(filename); !strings.Contains(string(content), "file only") {
+		t.Errorf("file should still be written, got %s", content)
+	}
+}
+
+func TestLoggerClose(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "close.log")
+	logger := New().
+		WithName("close-test").
+		WithFilename(filename).
+		WithoutStdout().
+		WithRetention(RetentionPolicy{MaxTotalBytes: 1 << 20}).
+		Init()
+	logger.Info("before close")
+
+	if err := logger.Sync(); err != nil {
+		t.Errorf("Sync() error = %v", err)
+	}
+	if err := logger.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if err := logger.Close(); err != nil {
+		t.Errorf("second Close() error = %v", err)
+	}
+	if lookupLogger("close-test") != nil {
+		t.Error("closed logger should be unregistered")
+	}
+	if content, _ := os.ReadFile(filename); !strings.Contains(string(content), "before close") {
+		t.Errorf("file should be flushed, got %s", content)
+	}
+}
+
+func TestLoggerSyncConsole(t *testing.T) {
+	// 输出到终端或管道时 Sync 不应返回错误
+	logger := New().WithFilename(filepath.Join(t.TempDir(), "sync.log")).WithStderr().Init()
+	defer logger.Close()
+	logger.Info("sync console")
+	if err := logger.Sync(); err != nil {
+		t.Errorf("Sync() error = %v", err)
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	previous := defaultLogger.Load()
+	defer SetDefault(previous)
+
+	filename := filepath.Join(t.TempDir(), "shutdown.log")
+	SetDefault(New().WithFilename(filename).WithoutStdout().WithTimeRotation(RotateDaily, nil).Init())
+	writer := L().writers[0].(*timeRotateWriter)
+	Info("before shutdown")
+
+	if err := Shutdown(); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	if writer.current != nil {
+		t.Error("log file should be closed")
+	}
+	if defaultLogger.Load() != nil {
+		t.Error("default logger should be cleared")
+	}
+	// 关闭后全局函数不再输出，也不会重新打开文件
+	Info("after shutdown")
+	if err := Shutdown(); err != nil {
+		t.Errorf("second Shutdown() error = %v", err)
+	}
+}
+
+func TestDefaultReplacesGlobal(t *testing.T) {
+	previous := defaultLogger.Load()
+	defer SetDefault(previous)
+	SetDefault(nil)
+
+	dir := t.TempDir()
+	first := New().WithFilename(filepath.Join(dir, "first.log")).WithoutStdout().Init()
+	defer first.Close()
+	if L() != first {
+		t.Fatal("New().Init() should become the default when none is set")
+	}
+	second := New().WithFilename(filepath.Join(dir, "second.log")).WithoutStdout().Init()
+	defer second.Close()
+	if L() != first {
+		t.Error("New().Init() should not replace an existing default")
+	}
+
+	replaced := Default().WithFilename(filepath.Join(dir, "replaced.log")).WithoutStdout().Init()
+	defer replaced.Close()
+	if L() != replaced {
+		t.Fatal("Default().Init() should replace the default")
+	}
+	Info("to replaced default")
+	if content, _ := os.ReadFile(filepath.Join(dir, "replaced.log")); !strings.Contains(string(content), "to replaced default") {
+		t.Errorf("global functions should use the replaced default, got %s", content)
+	}
+
+	SetDefault(nil)
+	if L() == nil {
+		t.Fatal("L() should never return nil")
+	}
+	L().Info("dropped")
+	Info("dropped")
+}
+
+func TestSetDefaultConcurrent(t *testing.T) {
+	previous := defaultLogger.Load()
+	defer SetDefault(previous)
+
+	dir := t.TempDir()
+	loggers := []*Logger{
+		New().WithFilename(filepath.Join(dir, "a.log")).WithoutStdout().Init(),
+		New().WithFilename(filepath.Join(dir, "b.log")).WithoutStdout().Init(),
+	}
+	for _, logger := range loggers {
+		defer logger.Close()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			Infof("concurrent %d", i)
+		}
+	}()
+	for i := 0; i < 1000; i++ {
+		SetDefault(loggers[i%2])
+	}
+	<-done
+}
+
+func TestLoggerWithNamed(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "child.log")
+	logger := New().WithFilename(filename).WithoutStdout().WithLevel(InfoLevel).Init()
+	defer logger.Close()
+
+	request := logger.With(zap.String("request_id", "r-1")).Named("http")
+	user := request.With(zap.Int("user_id", 42)).Named("users")
+	user.Info("child message")
+	logger.Info("parent message")
+
+	// 子日志器共享级别
+	logger.SetLevel(ErrorLevel)
+	user.Warn("filtered by parent level")
+	if user.Level() != ErrorLevel {
+		t.Errorf("child level = %v, want error", user.Level())
+	}
+
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines: %s", len(lines), content)
+	}
+	for _, want := range []string{`"logger":"http.users"`, `"request_id":"r-1"`, `"user_id":42`, `"msg":"child message"`, `"caller":"log/logger_test.go`} {
+		if !strings.Contains(lines[0], want) {
+			t.Errorf("child line %s should contain %s", lines[0], want)
+		}
+	}
+	if strings.Contains(lines[1], "request_id") || strings.Contains(lines[1], `"logger"`) {
+		t.Errorf("parent should not carry child fields: %s", lines[1])
+	}
+
+	if err := user.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if user.root != logger || request.root != logger {
+		t.Error("children should share the root logger")
+	}
+}
+
+// waitFor 等待后台任务使 cond 成立，5 秒后仍不成立时测试失败
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met within 5s")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
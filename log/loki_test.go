@@ -0,0 +1,314 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// lokiServer 记录收到的推送请求，statuses 依次作为响应状态码，用完后返回 204
+type lokiServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []*http.Request
+	bodies   [][]byte
+	statuses []int
+}
+
+func newLokiServer(t *testing.T, statuses ...int) *lokiServer {
+	s := &lokiServer{statuses: statuses}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		s.mu.Lock()
+		s.requests = append(s.requests, r)
+		s.bodies = append(s.bodies, body)
+		status := http.StatusNoContent
+		if len(s.statuses) != 0 {
+			status, s.statuses = s.statuses[0], s.statuses[1:]
+		}
+		s.mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *lokiServer) snapshot() ([]*http.Request, [][]byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*http.Request(nil), s.requests...), append([][]byte(nil), s.bodies...)
+}
+
+func newLokiLogger(t *testing.T, opts LokiOptions) *Logger {
+	logger, err := New().
+		WithFilename(filepath.Join(t.TempDir(), "app.log")).
+		WithoutStdout().
+		WithFields(map[string]any{"service": "api", "version": "1.2.0", "region": "eu"}).
+		WithLoki(opts).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return logger
+}
+
+func TestLokiSink_JSON(t *testing.T) {
+	server := newLokiServer(t)
+	logger := newLokiLogger(t, LokiOptions{
+		URL:       server.URL,
+		Labels:    []string{"service", "version"},
+		TenantID:  "team-a",
+		BatchWait: time.Hour,
+	})
+	logger.Info("hello loki", zap.Int("n", 1))
+	logger.Error("failed")
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	requests, bodies := server.snapshot()
+	if len(requests) != 1 {
+		t.Fatalf("requests = %d, want 1", len(requests))
+	}
+	if requests[0].URL.Path != DefaultLokiPushPath || requests[0].Header.Get("Content-Type") != "application/json" ||
+		requests[0].Header.Get("X-Scope-OrgID") != "team-a" {
+		t.Errorf("request = %s %s", requests[0].URL.Path, requests[0].Header)
+	}
+
+	var push struct {
+		Streams []struct {
+			Stream map[string]string `json:"stream"`
+			Values [][2]string       `json:"values"`
+		} `json:"streams"`
+	}
+	if err := json.Unmarshal(bodies[0], &push); err != nil {
+		t.Fatalf("%v: %s", err, bodies[0])
+	}
+	if len(push.Streams) != 2 {
+		t.Fatalf("streams = %+v", push.Streams)
+	}
+	info := push.Streams[0]
+	if info.Stream["level"] != "info" || info.Stream["service"] != "api" || info.Stream["version"] != "1.2.0" ||
+		len(info.Stream) != 3 {
+		t.Errorf("labels = %v", info.Stream)
+	}
+	if push.Streams[1].Stream["level"] != "error" {
+		t.Errorf("labels = %v", push.Streams[1].Stream)
+	}
+
+	var line map[string]any
+	if err := json.Unmarshal([]byte(info.Values[0][1]), &line); err != nil {
+		t.Fatal(err)
+	}
+	if line["msg"] != "hello loki" || line["n"] != float64(1) || line["region"] != "eu" {
+		t.Errorf("line = %v", line)
+	}
+	if _, ok := line["service"]; ok {
+		t.Errorf("label fields should not be written to the line: %v", line)
+	}
+}
+
+func TestLokiSink_Protobuf(t *testing.T) {
+	server := newLokiServer(t)
+	logger := newLokiLogger(t, LokiOptions{
+		URL:       server.URL + "/custom/push",
+		Labels:    []string{"service"},
+		Format:    LokiProtobuf,
+		BatchWait: time.Hour,
+	})
+	logger.Warn("compressed")
+	if err := logger.Sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	requests, bodies := server.snapshot()
+	if len(requests) != 1 || requests[0].URL.Path != "/custom/push" ||
+		requests[0].Header.Get("Content-Type") != "application/x-protobuf" {
+		t.Fatalf("requests = %d", len(requests))
+	}
+	data, err := snappy.Decode(nil, bodies[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`{level="warn", service="api"}`, `"msg":"compressed"`} {
+		if !bytes.Contains(data, []byte(want)) {
+			t.Errorf("payload %q does not contain %s", data, want)
+		}
+	}
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLokiSink_LabelValues(t *testing.T) {
+	server := newLokiServer(t)
+	for _, format := range []string{LokiJSON, LokiProtobuf} {
+		logger, err := New().
+			WithFilename(filepath.Join(t.TempDir(), "app.log")).
+			WithoutStdout().
+			WithFields(map[string]any{"service": "a, b", "region": `eu"west`}).
+			WithLoki(LokiOptions{URL: server.URL, Labels: []string{"service", "region"}, Format: format, BatchWait: time.Hour}).
+			Build()
+		if err != nil {
+			t.Fatal(err)
+		}
+		logger.Info("labels")
+		if err := logger.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, bodies := server.snapshot()
+	if len(bodies) != 2 {
+		t.Fatalf("bodies = %d, want 2", len(bodies))
+	}
+	var push struct {
+		Streams []struct {
+			Stream map[string]string `json:"stream"`
+		} `json:"streams"`
+	}
+	if err := json.Unmarshal(bodies[0], &push); err != nil {
+		t.Fatal(err)
+	}
+	if stream := push.Streams[0].Stream; stream["service"] != "a, b" || stream["region"] != `eu"west` {
+		t.Errorf("labels = %v", stream)
+	}
+	data, err := snappy.Decode(nil, bodies[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{level="info", region="eu\"west", service="a, b"}`; !bytes.Contains(data, []byte(want)) {
+		t.Errorf("payload %q does not contain %s", data, want)
+	}
+}
+
+// waitLokiBodies 等待服务端收到 n 个推送请求
+func waitLokiBodies(t *testing.T, server *lokiServer, n int) [][]byte {
+	t.Helper()
+	var bodies [][]byte
+	waitFor(t, func() bool {
+		_, bodies = server.snapshot()
+		return len(bodies) >= n
+	})
+	return bodies
+}
+
+func TestLokiSink_Retry(t *testing.T) {
+	server := newLokiServer(t, http.StatusServiceUnavailable, http.StatusTooManyRequests)
+	logger := newLokiLogger(t, LokiOptions{URL: server.URL, BatchSize: 1, BatchWait: time.Hour})
+	defer logger.Close()
+
+	// 后台推送失败时重试
+	logger.Info("retried")
+	if bodies := waitLokiBodies(t, server, 3); len(bodies) != 3 || !bytes.Equal(bodies[0], bodies[2]) {
+		t.Errorf("bodies = %q", bodies)
+	}
+
+	// 4xx 不重试，批次按顺序推送，下一个请求即是后一条日志
+	server.mu.Lock()
+	server.statuses = []int{http.StatusBadRequest}
+	server.mu.Unlock()
+	logger.Info("rejected")
+	waitLokiBodies(t, server, 4)
+	logger.Info("accepted")
+	bodies := waitLokiBodies(t, server, 5)
+	if !bytes.Contains(bodies[3], []byte("rejected")) || !bytes.Contains(bodies[4], []byte("accepted")) {
+		t.Errorf("bodies = %q", bodies[3:])
+	}
+}
+
+func TestLokiSink_CloseDoesNotRetry(t *testing.T) {
+	server := newLokiServer(t, http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+	logger := newLokiLogger(t, LokiOptions{URL: server.URL, BatchWait: time.Hour})
+	logger.Info("lost")
+
+	start := time.Now()
+	if err := logger.Close(); err == nil || !strings.Contains(err.Error(), "503") {
+		t.Errorf("Close() = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 300*time.Millisecond {
+		t.Errorf("Close took %s", elapsed)
+	}
+	if _, bodies := server.snapshot(); len(bodies) != 1 {
+		t.Errorf("bodies = %d, want 1", len(bodies))
+	}
+}
+
+func TestLokiSink_BufferLimit(t *testing.T) {
+	server := newLokiServer(t)
+	logger := newLokiLogger(t, LokiOptions{URL: server.URL, BatchWait: time.Hour, MaxBufferBytes: 200})
+	core := logger.zapLogger.Core()
+
+	var dropped int
+	for i := 0; i < 10; i++ {
+		if err := core.Write(zapcore.Entry{Level: zapcore.InfoLevel, Message: "buffered entry"}, nil); err != nil {
+			dropped++
+		}
+	}
+	if dropped == 0 || dropped == 10 {
+		t.Errorf("dropped = %d", dropped)
+	}
+
+	// 推送后释放缓冲
+	if err := logger.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if err := core.Write(zapcore.Entry{Level: zapcore.InfoLevel, Message: "after sync"}, nil); err != nil {
+		t.Errorf("Write after Sync = %v", err)
+	}
+	_ = logger.Close()
+}
+
+func TestLokiSink_BasicAuth(t *testing.T) {
+	server := newLokiServer(t)
+	u, _ := url.Parse("loki+" + strings.Replace(server.URL, "://", "://admin:s3cret@", 1))
+	sink, err := newLokiSink(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+
+	// 推送失败时输出到 stderr 的描述不包含密码
+	s := sink.(*lokiSink)
+	if strings.Contains(s.endpoint, "s3cret") || strings.Contains(s.batcher.name, "s3cret") {
+		t.Errorf("endpoint = %s, name = %s", s.endpoint, s.batcher.name)
+	}
+
+	if _, err := sink.Write([]byte("authorized\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	requests, _ := server.snapshot()
+	if len(requests) != 1 {
+		t.Fatalf("requests = %d, want 1", len(requests))
+	}
+	if username, password, ok := requests[0].BasicAuth(); !ok || username != "admin" || password != "s3cret" {
+		t.Errorf("basic auth = %s:%s, %v", username, password, ok)
+	}
+}
+
+func TestWithLoki_Invalid(t *testing.T) {
+	for _, opts := range []LokiOptions{
+		{URL: "ftp://loki:3100"},
+		{URL: "http://loki:3100", Format: "xml"},
+		{URL: "http://loki:3100", BatchSize: -1},
+	} {
+		if _, err := New().WithoutStdout().WithLoki(opts).Build(); err == nil {
+			t.Errorf("WithLoki(%+v) should fail", opts)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package log
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// DefaultRequestIDHeader HTTPMiddleware 默认读取和回写请求 id 的请求头
+const DefaultRequestIDHeader = "X-Request-Id"
+
+// MiddlewareOptions HTTPMiddleware 的选项，零值即可使用
+type MiddlewareOptions struct {
+	// RequestIDHeader 读取和回写请求 id 的请求头，为空时使用 DefaultRequestIDHeader
+	RequestIDHeader string
+	// NewRequestID 请求中没有请求 id 时用于生成，为空时生成 32 位随机十六进制字符串
+	NewRequestID func() string
+	// StatusLevels 按状态码类别设置访问日志的级别，key 为 1 到 5 分别对应 1xx 到 5xx，
+	// 未设置的类别中 4xx 使用 WarnLevel，5xx 使用 ErrorLevel，其余使用 InfoLevel
+	StatusLevels map[int]Level
+	// Message 访问日志的内容，为空时使用 "http request"
+	Message string
+}
+
+func (o MiddlewareOptions) statusLevel(status int) zapcore.Level {
+	class := status / 100
+	if level, ok := o.StatusLevels[class]; ok {
+		return zapcore.Level(level)
+	}
+	switch class {
+	case 4:
+		return zapcore.WarnLevel
+	case 5:
+		return zapcore.ErrorLevel
+	default:
+		return zapcore.InfoLevel
+	}
+}
+
+// HTTPMiddleware 为每个请求分配或沿用请求 id 并写回响应头，将带有 request_id 字段的子日志器放入请求的 context，
+// 可通过 FromContext 取出，请求结束时输出包含 method、path、status、bytes、latency、remote_addr 的访问日志。
+// logger 为 nil 时使用请求时的默认实例
+func HTTPMiddleware(logger *Logger, opts MiddlewareOptions) func(http.Handler) http.Handler {
+	if opts.RequestIDHeader == "" {
+		opts.RequestIDHeader = DefaultRequestIDHeader
+	}
+	if opts.NewRequestID == nil {
+		opts.NewRequestID = newRequestID
+	}
+	if opts.Message == "" {
+		opts.Message = "http request"
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			requestID := r.Header.Get(opts.RequestIDHeader)
+			if requestID == "" {
+				requestID = opts.NewRequestID()
+			}
+			w.Header().Set(opts.RequestIDHeader, requestID)
+
+			base := logger
+			if base == nil {
+				base = L()
+			}
+			child := base.With(zap.String("request_id", requestID))
+			ctx := WithContext(r.Context(), child)
+
+			recorder := &responseRecorder{ResponseWriter: w}
+			next.ServeHTTP(recorder, r.WithContext(ctx))
+
+			status := recorder.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+			// 直接调用 zapLogger，不经过 Logger 的方法，需要抵消构造时的 AddCallerSkip(1)
+			if ce := child.zapLogger.WithOptions(zap.AddCallerSkip(-1)).Check(opts.statusLevel(status), opts.Message); ce != nil {
+				ce.Write(child.contextFields(ctx, []zap.Field{
+					zap.String("method", r.Method),
+					zap.String("path", r.URL.Path),
+					zap.Int("status", status),
+					zap.Int64("bytes", recorder.bytes),
+					zap.Duration("latency", time.Since(start)),
+					zap.String("remote_addr", r.RemoteAddr),
+				})...)
+			}
+		})
+	}
+}
+
+func newRequestID() string {
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(id[:])
+}
+
+// responseRecorder 记录响应的状态码和字节数
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(p []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	n, err := r.ResponseWriter.Write(p)
+	r.bytes += int64(n)
+	return n, err
+}
+
+// Flush 支持流式响应
+func (r *responseRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack 支持 WebSocket 等需要接管连接的处理器，接管后状态码记为 101
+func (r *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("%T does not implement http.Hijacker", r.ResponseWriter)
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err == nil && r.status == 0 {
+		r.status = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
+
+// Unwrap 供 http.ResponseController 访问原始的 ResponseWriter
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
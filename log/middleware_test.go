@@ -0,0 +1,146 @@
+package log
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func readLogEntries(t *testing.T, filename string) []map[string]any {
+	t.Helper()
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entries []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(string(content)), "\n") {
+		entry := make(map[string]any)
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("invalid line %s: %v", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestHTTPMiddleware(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "access.log")
+	logger := New().WithFilename(filename).WithoutStdout().WithLevel(InfoLevel).Init()
+	defer logger.Close()
+
+	handler := HTTPMiddleware(logger, MiddlewareOptions{
+		NewRequestID: func() string { return "generated" },
+		StatusLevels: map[int]Level{4: ErrorLevel},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		FromContext(r.Context()).Info("handling")
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte("hello"))
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/hello", nil)
+	req.Header.Set(DefaultRequestIDHeader, "incoming")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if got := rec.Header().Get(DefaultRequestIDHeader); got != "incoming" {
+		t.Errorf("response request id = %s, want incoming", got)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/missing", nil))
+	if got := rec.Header().Get(DefaultRequestIDHeader); got != "generated" {
+		t.Errorf("response request id = %s, want generated", got)
+	}
+
+	entries := readLogEntries(t, filename)
+	if len(entries) != 4 {
+		t.Fatalf("got %d entries: %v", len(entries), entries)
+	}
+	if entries[0]["msg"] != "handling" || entries[0]["request_id"] != "incoming" ||
+		!strings.HasPrefix(entries[0]["caller"].(string), "log/middleware_test.go:") {
+		t.Errorf("handler entry = %v", entries[0])
+	}
+	access := entries[1]
+	if access["msg"] != "http request" || access["level"] != "info" || access["request_id"] != "incoming" ||
+		access["method"] != "GET" || access["path"] != "/hello" || access["status"] != float64(200) || access["bytes"] != float64(5) ||
+		!strings.HasPrefix(access["caller"].(string), "log/middleware.go:") {
+		t.Errorf("access entry = %v", access)
+	}
+	for _, key := range []string{"latency", "remote_addr"} {
+		if _, ok := access[key]; !ok {
+			t.Errorf("access entry should contain %s: %v", key, access)
+		}
+	}
+	if entries[3]["level"] != "error" || entries[3]["status"] != float64(404) || entries[3]["request_id"] != "generated" {
+		t.Errorf("404 entry = %v", entries[3])
+	}
+}
+
+func TestHTTPMiddleware_Hijack(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "access.log")
+	logger := New().WithFilename(filename).WithoutStdout().Init()
+	defer logger.Close()
+
+	done := make(chan struct{})
+	handler := HTTPMiddleware(logger, MiddlewareOptions{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hijacker, ok := w.(http.Hijacker)
+		if !ok {
+			t.Error("ResponseWriter should implement http.Hijacker")
+			return
+		}
+		conn, rw, err := hijacker.Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		_, _ = rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: test\r\nConnection: Upgrade\r\n\r\n")
+		_ = rw.Flush()
+	}))
+	// 接管的连接不受 server.Close 管理，等待中间件写完访问日志
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer close(done)
+		handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "test")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Errorf("status = %d, want 101", resp.StatusCode)
+	}
+	<-done
+
+	entries := readLogEntries(t, filename)
+	if len(entries) != 1 || entries[0]["status"] != float64(http.StatusSwitchingProtocols) {
+		t.Errorf("entries = %v", entries)
+	}
+
+	// 底层不支持接管时返回错误
+	recorder := &responseRecorder{ResponseWriter: httptest.NewRecorder()}
+	if _, _, err := recorder.Hijack(); err == nil {
+		t.Error("Hijack should fail when the ResponseWriter is not a http.Hijacker")
+	}
+}
+
+func TestMiddlewareOptions_StatusLevel(t *testing.T) {
+	opts := MiddlewareOptions{StatusLevels: map[int]Level{2: DebugLevel}}
+	tests := map[int]Level{200: DebugLevel, 301: InfoLevel, 404: WarnLevel, 503: ErrorLevel}
+	for status, want := range tests {
+		if got := opts.statusLevel(status); Level(got) != want {
+			t.Errorf("statusLevel(%d) = %v, want %v", status, got, want)
+		}
+	}
+}
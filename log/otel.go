@@ -0,0 +1,22 @@
+package log
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// OTelExtractor 从 ctx 中当前的 OpenTelemetry span 提取 W3C 格式的 trace_id、span_id 和 trace_flags，
+// 通过 WithContextExtractor(OTelExtractor) 开启，ctx 中没有有效的 span 时不输出这些字段
+func OTelExtractor(ctx context.Context) []zap.Field {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.IsValid() {
+		return nil
+	}
+	return []zap.Field{
+		zap.String("trace_id", spanContext.TraceID().String()),
+		zap.String("span_id", spanContext.SpanID().String()),
+		zap.String("trace_flags", spanContext.TraceFlags().String()),
+	}
+}
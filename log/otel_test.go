@@ -0,0 +1,39 @@
+package log
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestOTelExtractor(t *testing.T) {
+	if fields := OTelExtractor(context.Background()); fields != nil {
+		t.Errorf("fields without span = %v, want nil", fields)
+	}
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	}))
+
+	filename := filepath.Join(t.TempDir(), "otel.log")
+	logger := New().WithFilename(filename).WithoutStdout().WithContextExtractor(OTelExtractor).Init()
+	defer logger.Close()
+	logger.InfoCtx(ctx, "traced")
+
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `"trace_id":"4bf92f3577b34da6a3ce929d0e0e4736","span_id":"00f067aa0ba902b7","trace_flags":"01"`
+	if !strings.Contains(string(content), want) {
+		t.Errorf("%s should contain %s", content, want)
+	}
+}
@@ -0,0 +1,224 @@
+package log
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// DefaultRetentionInterval RetentionPolicy 未设置 Interval 时的检查间隔
+const DefaultRetentionInterval = 10 * time.Minute
+
+// RetentionPolicy 按总大小清理日志文件的策略，主日志文件、warn、error 日志文件以及
+// 按时间或大小切分出的旧文件共用同一个额度，超出时从最旧的文件开始删除，正在写入的文件不会被删除
+type RetentionPolicy struct {
+	// MaxTotalBytes 所有日志文件的总大小上限，单位字节
+	MaxTotalBytes int64
+	// Interval 后台检查的间隔，为 0 时使用 DefaultRetentionInterval
+	Interval time.Duration
+}
+
+// WithRetention 开启按总大小清理日志文件，清理在后台进行，删除的文件会通过日志器本身记录
+func (c *Config) WithRetention(policy RetentionPolicy) *Config {
+	c.rollingConfig.retention = &policy
+	return c
+}
+
+func (p RetentionPolicy) validate() error {
+	if p.MaxTotalBytes <= 0 {
+		return fmt.Errorf("max total bytes must be positive, got %d", p.MaxTotalBytes)
+	}
+	if p.Interval < 0 {
+		return fmt.Errorf("negative interval %s", p.Interval)
+	}
+	return nil
+}
+
+// retentionManager 定期检查日志文件的总大小并删除最旧的文件
+type retentionManager struct {
+	policy   RetentionPolicy
+	files    []logFile
+	logger   *Logger
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func newRetentionManager(policy RetentionPolicy, files []logFile, logger *Logger) *retentionManager {
+	if policy.Interval == 0 {
+		policy.Interval = DefaultRetentionInterval
+	}
+	return &retentionManager{
+		policy: policy,
+		files:  files,
+		logger: logger,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+}
+
+func (m *retentionManager) start() {
+	go m.run()
+}
+
+func (m *retentionManager) run() {
+	defer close(m.done)
+
+	ticker := time.NewTicker(m.policy.Interval)
+	defer ticker.Stop()
+	for {
+		m.enforce(time.Now())
+		select {
+		case <-ticker.C:
+		case <-m.stop:
+			return
+		}
+	}
+}
+
+// close 停止后台清理并等待正在进行的清理结束
+func (m *retentionManager) close() {
+	m.stopOnce.Do(func() {
+		close(m.stop)
+	})
+	<-m.done
+}
+
+type retainedFile struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// enforce 删除最旧的文件直到总大小不超过额度，返回删除的文件
+func (m *retentionManager) enforce(now time.Time) []string {
+	files, err := m.collect()
+	if err != nil {
+		m.logger.Warn("retention failed to list log files", zap.Error(err))
+		return nil
+	}
+
+	var total int64
+	for _, file := range files {
+		total += file.size
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime.Before(files[j].modTime)
+	})
+
+	active := make(map[string]bool)
+	for _, file := range m.files {
+		if path, err := filepath.Abs(file.filenameAt(now)); err == nil {
+			active[path] = true
+		}
+	}
+
+	var removed []string
+	for _, file := range files {
+		if total <= m.policy.MaxTotalBytes {
+			break
+		}
+		if active[file.path] {
+			continue
+		}
+		if err := os.Remove(file.path); err != nil {
+			m.logger.Warn("retention failed to remove log file", zap.String("file", file.path), zap.Error(err))
+			continue
+		}
+		total -= file.size
+		removed = append(removed, file.path)
+		m.logger.Info("retention removed log file",
+			zap.String("file", file.path),
+			zap.Int64("size", file.size),
+			zap.Int64("max_total_bytes", m.policy.MaxTotalBytes),
+		)
+	}
+	return removed
+}
+
+// collect 找出所有日志文件产生的文件，包括按时间切分的 name_<time>[_level].ext 与 lumberjack 切分的 name-<time>.ext(.gz)，
+// 只匹配时间戳能够解析的文件名，同一目录下前缀相同的其他文件不受影响
+func (m *retentionManager) collect() ([]retainedFile, error) {
+	seen := make(map[string]bool)
+	var files []retainedFile
+	for _, file := range m.files {
+		dir := filepath.Dir(file.filename)
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		for _, entry := range entries {
+			if !file.produces(entry.Name()) {
+				continue
+			}
+			path, err := filepath.Abs(filepath.Join(dir, entry.Name()))
+			if err != nil || seen[path] {
+				continue
+			}
+			seen[path] = true
+
+			info, err := os.Stat(path)
+			if err != nil || !info.Mode().IsRegular() {
+				continue
+			}
+			files = append(files, retainedFile{path: path, size: info.Size(), modTime: info.ModTime()})
+		}
+	}
+	return files, nil
+}
+
+// lumberjackBackupLayout lumberjack 备份文件名中的时间格式
+const lumberjackBackupLayout = "2006-01-02T15-04-05.000"
+
+// produces 判断 name 是否为该日志文件写入的文件或 lumberjack 的备份
+func (f logFile) produces(name string) bool {
+	ext := filepath.Ext(f.filename)
+	compressed := strings.HasSuffix(name, ext+".gz")
+	if compressed {
+		name = strings.TrimSuffix(name, ".gz")
+	}
+	if !strings.HasSuffix(name, ext) {
+		return false
+	}
+	stem := strings.TrimSuffix(name, ext)
+	if !compressed && f.currentStem(stem) {
+		return true
+	}
+	i := len(stem) - len(lumberjackBackupLayout) - 1
+	if i <= 0 || stem[i] != '-' {
+		return false
+	}
+	if _, err := time.Parse(lumberjackBackupLayout, stem[i+1:]); err != nil {
+		return false
+	}
+	return f.currentStem(stem[:i])
+}
+
+// currentStem 判断不含扩展名的 stem 是否为 stampFilename 生成的文件名
+func (f logFile) currentStem(stem string) bool {
+	filename := filepath.Base(f.filename)
+	prefix := strings.TrimSuffix(filename, filepath.Ext(filename))
+	if f.level != "" {
+		if !strings.HasSuffix(stem, "_"+f.level) {
+			return false
+		}
+		stem = strings.TrimSuffix(stem, "_"+f.level)
+	}
+	if f.rotation == nil {
+		return stem == prefix
+	}
+	if !strings.HasPrefix(stem, prefix+"_") {
+		return false
+	}
+	_, err := time.Parse(f.rotation.layout(), strings.TrimPrefix(stem, prefix+"_"))
+	return err == nil
+}
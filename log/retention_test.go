@@ -0,0 +1,150 @@
+package log
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeRetainedFile(t *testing.T, path string, size int, modTime time.Time) {
+	t.Helper()
+	if err := os.WriteFile(path, make([]byte, size), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRetentionManager_Enforce(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	filename := filepath.Join(dir, "app.log")
+	warnFilename := filepath.Join(dir, "warn.log")
+
+	writeRetainedFile(t, filepath.Join(dir, "app-2024-03-01T00-00-00.000.log.gz"), 100, now.Add(-4*time.Hour))
+	writeRetainedFile(t, filepath.Join(dir, "warn-2024-03-02T00-00-00.000.log"), 100, now.Add(-3*time.Hour))
+	writeRetainedFile(t, filepath.Join(dir, "app-2024-03-03T00-00-00.000.log"), 100, now.Add(-2*time.Hour))
+	writeRetainedFile(t, filepath.Join(dir, "unrelated.log"), 1000, now.Add(-5*time.Hour))
+
+	c := New().WithFilename(filename).WithWarnLog(warnFilename).WithoutStdout()
+	logger := c.Init()
+	m := newRetentionManager(RetentionPolicy{MaxTotalBytes: 250}, c.logFiles(nil), logger)
+
+	// 正在写入的文件即使最旧也不会被删除
+	writeRetainedFile(t, warnFilename, 100, now.Add(-6*time.Hour))
+
+	removed := m.enforce(now)
+	if len(removed) != 2 {
+		t.Fatalf("removed = %v, want 2 files", removed)
+	}
+	for i, name := range []string{"app-2024-03-01T00-00-00.000.log.gz", "warn-2024-03-02T00-00-00.000.log"} {
+		if filepath.Base(removed[i]) != name {
+			t.Errorf("removed[%d] = %s, want %s", i, removed[i], name)
+		}
+	}
+	for _, name := range []string{"warn.log", "unrelated.log", "app-2024-03-03T00-00-00.000.log"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("%s should be kept: %v", name, err)
+		}
+	}
+
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(string(content), "retention removed log file") != 2 {
+		t.Errorf("removals should be logged, got %s", content)
+	}
+}
+
+func TestRetentionManager_TimeRotatedFiles(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	c := New().
+		WithFilename(filepath.Join(dir, "app.log")).
+		WithTimeRotation(RotateDaily, nil).
+		WithoutStdout()
+	files := c.logFiles(nil)
+
+	writeRetainedFile(t, filepath.Join(dir, "app_2024-03-01.log"), 100, now.Add(-48*time.Hour))
+	writeRetainedFile(t, filepath.Join(dir, "app_2024-03-02.log"), 100, now.Add(-24*time.Hour))
+	writeRetainedFile(t, files[0].filenameAt(now), 100, now)
+
+	logger := c.Init()
+	m := newRetentionManager(RetentionPolicy{MaxTotalBytes: 200}, files, logger)
+	removed := m.enforce(now)
+	if len(removed) != 1 || filepath.Base(removed[0]) != "app_2024-03-01.log" {
+		t.Errorf("removed = %v, want app_2024-03-01.log", removed)
+	}
+}
+
+func TestRetentionManager_SiblingFiles(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	c := New().
+		WithFilename(filepath.Join(dir, "api.log")).
+		WithTimeRotation(RotateDaily, nil).
+		WithoutStdout()
+	files := c.logFiles(nil)
+	// 与未指定文件名的 warn 日志相同，在主日志文件名后追加级别
+	warn := files[0]
+	warn.level = "warn"
+	files = append(files, warn)
+
+	produced := []string{
+		"api_2024-03-01.log",
+		"api_2024-03-01_warn.log",
+		"api_2024-03-02-2024-03-02T10-00-00.000.log.gz",
+		"api_2024-03-02_warn-2024-03-02T10-00-00.000.log",
+	}
+	siblings := []string{
+		"api.log",
+		"api-gateway.log",
+		"api_audit.log",
+		"api_audit.log.gz",
+		"api-2024-03-01.log",
+		"api_2024-03-01_error.log",
+		"api_2024-03-01.log.gz",
+	}
+	for _, name := range append(append([]string(nil), produced...), siblings...) {
+		writeRetainedFile(t, filepath.Join(dir, name), 100, now.Add(-48*time.Hour))
+	}
+
+	logger := c.Init()
+	m := newRetentionManager(RetentionPolicy{MaxTotalBytes: 1}, files, logger)
+	removed := m.enforce(now)
+	if len(removed) != len(produced) {
+		t.Errorf("removed = %v, want %v", removed, produced)
+	}
+	for _, name := range siblings {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("%s should survive: %v", name, err)
+		}
+	}
+}
+
+func TestConfig_WithRetention(t *testing.T) {
+	dir := t.TempDir()
+	old := filepath.Join(dir, "app-2024-03-01T00-00-00.000.log")
+	writeRetainedFile(t, old, 1000, time.Now().Add(-time.Hour))
+
+	logger := New().
+		WithFilename(filepath.Join(dir, "app.log")).
+		WithoutStdout().
+		WithRetention(RetentionPolicy{MaxTotalBytes: 500, Interval: 10 * time.Millisecond}).
+		Init()
+	defer logger.retention.close()
+
+	// 旧文件在后台被删除
+	waitFor(t, func() bool {
+		_, err := os.Stat(old)
+		return os.IsNotExist(err)
+	})
+
+	if _, err := New().WithFilename(filepath.Join(dir, "bad.log")).WithRetention(RetentionPolicy{}).Build(); err == nil {
+		t.Error("Build() should reject an empty retention budget")
+	}
+}
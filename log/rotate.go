@@ -0,0 +1,218 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+const (
+	// RotateHourly 每小时切换一次日志文件
+	RotateHourly = time.Hour
+	// RotateDaily 每天零点切换一次日志文件
+	RotateDaily = 24 * time.Hour
+)
+
+// fileWriter 日志文件的写入器，lumberjack.Logger 与 timeRotateWriter 都实现了该接口
+type fileWriter interface {
+	io.Writer
+	io.Closer
+}
+
+// RotationPolicy 日志文件按大小切分及清理旧文件的策略，对主日志文件和 warn、error 日志文件同时生效
+type RotationPolicy struct {
+	// MaxSize 单个日志文件的最大尺寸，单位 MB，为 0 时使用 lumberjack 的默认值 100MB
+	MaxSize int
+	// MaxAge 切分出的旧文件保留的天数，为 0 时不按时间清理
+	MaxAge int
+	// MaxBackups 保留的旧文件数量，为 0 时不限制
+	MaxBackups int
+	// Compress 是否使用 gzip 压缩切分出的旧文件
+	Compress bool
+	// LocalTime 旧文件名中的时间是否使用本地时间，默认使用 UTC
+	LocalTime bool
+}
+
+// WithRotation 设置日志文件按大小切分及清理旧文件的策略。policy 整体替换当前策略，
+// 未设置的字段为零值而不会保留 New 的默认值（500MB、30 天），只调整部分字段时
+// 应从 Rotation 返回的当前策略开始修改：
+//
+//	policy := c.Rotation()
+//	policy.Compress = true
+//	c.WithRotation(policy)
+func (c *Config) WithRotation(policy RotationPolicy) *Config {
+	c.rollingConfig.logger.MaxSize = policy.MaxSize
+	c.rollingConfig.logger.MaxAge = policy.MaxAge
+	c.rollingConfig.logger.MaxBackups = policy.MaxBackups
+	c.rollingConfig.logger.Compress = policy.Compress
+	c.rollingConfig.logger.LocalTime = policy.LocalTime
+	return c
+}
+
+// Rotation 返回当前的切分策略
+func (c *Config) Rotation() RotationPolicy {
+	return RotationPolicy{
+		MaxSize:    c.rollingConfig.logger.MaxSize,
+		MaxAge:     c.rollingConfig.logger.MaxAge,
+		MaxBackups: c.rollingConfig.logger.MaxBackups,
+		Compress:   c.rollingConfig.logger.Compress,
+		LocalTime:  c.rollingConfig.logger.LocalTime,
+	}
+}
+
+func (p RotationPolicy) validate() error {
+	if p.MaxSize < 0 || p.MaxAge < 0 || p.MaxBackups < 0 {
+		return fmt.Errorf("negative value in %+v", p)
+	}
+	return nil
+}
+
+// timeRotation 按时间周期切换日志文件，周期边界按 location 所在时区计算
+type timeRotation struct {
+	period   time.Duration
+	location *time.Location
+}
+
+// WithTimeRotation 按时间周期切换日志文件，period 可以是 RotateDaily、RotateHourly 或自定义周期，
+// 周期边界按 location 所在时区计算，location 为空时使用本地时区。
+// 开启后主日志文件及 warn、error 日志文件的文件名中都会带上当前周期的起始时间，
+// 每个周期内仍按 MaxSize 切分文件
+func (c *Config) WithTimeRotation(period time.Duration, location *time.Location) *Config {
+	if location == nil {
+		location = time.Local
+	}
+	c.rollingConfig.timeRotation = &timeRotation{
+		period:   period,
+		location: location,
+	}
+	return c
+}
+
+// fileRotation 显式设置了 WithTimeRotation 时所有文件都按该周期切换，否则只有默认文件名按天切换，
+// 与默认文件名中带有日期的行为保持一致
+func (c *Config) fileRotation(defaultFilename bool) *timeRotation {
+	if c.rollingConfig.timeRotation != nil {
+		return c.rollingConfig.timeRotation
+	}
+	if defaultFilename {
+		return &timeRotation{period: RotateDaily, location: time.Local}
+	}
+	return nil
+}
+
+func (r *timeRotation) validate() error {
+	switch {
+	case r.period < time.Minute:
+		return fmt.Errorf("period %s is shorter than 1m", r.period)
+	case r.period > RotateDaily && r.period%RotateDaily != 0:
+		return fmt.Errorf("period %s longer than 24h must be whole days", r.period)
+	}
+	return nil
+}
+
+// start 返回 t 所在周期的起始时间，超过一天的周期按 Unix 纪元起的天数对齐，
+// 不足一天的周期从当天零点开始计算，保证进程重启后周期边界不变
+func (r *timeRotation) start(t time.Time) time.Time {
+	t = t.In(r.location)
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, r.location)
+	if r.period >= RotateDaily {
+		days := int(r.period / RotateDaily)
+		epochDays := int(time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC).Unix() / 86400)
+		return midnight.AddDate(0, 0, -(epochDays % days))
+	}
+	elapsed := t.Sub(midnight)
+	return midnight.Add(elapsed - elapsed%r.period)
+}
+
+// next 返回 start 所在周期的结束时间，不足一天的周期不会跨过零点
+func (r *timeRotation) next(start time.Time) time.Time {
+	if r.period >= RotateDaily {
+		return start.AddDate(0, 0, int(r.period/RotateDaily))
+	}
+	next := start.Add(r.period)
+	midnight := time.Date(start.Year(), start.Month(), start.Day()+1, 0, 0, 0, 0, r.location)
+	if next.After(midnight) {
+		return midnight
+	}
+	return next
+}
+
+func (r *timeRotation) layout() string {
+	switch {
+	case r.period >= RotateDaily:
+		return "2006-01-02"
+	case r.period%time.Hour == 0:
+		return "2006-01-02-15"
+	default:
+		return "2006-01-02-15-04"
+	}
+}
+
+// stampFilename 在文件名与扩展名之间插入时间戳和级别，如 ./app.log -> ./app_2006-01-02_warn.log
+func stampFilename(rawFilename string, stamp string, level string) string {
+	if rawFilename == "" {
+		return rawFilename
+	}
+	filename := filepath.Base(rawFilename)
+	suffix := filepath.Ext(filename)
+	filenameOnly := strings.TrimSuffix(filename, suffix)
+	if stamp != "" {
+		filenameOnly += "_" + stamp
+	}
+	if level != "" {
+		filenameOnly += "_" + level
+	}
+	return strings.TrimSuffix(rawFilename, filename) + filenameOnly + suffix
+}
+
+// timeRotateWriter 在周期边界切换到新的 lumberjack.Logger，写入时才检查是否需要切换
+type timeRotateWriter struct {
+	mu        sync.Mutex
+	file      logFile
+	newLogger func(filename string) *lumberjack.Logger
+	now       func() time.Time
+	current   *lumberjack.Logger
+	next      time.Time
+}
+
+func newTimeRotateWriter(file logFile, newLogger func(filename string) *lumberjack.Logger) *timeRotateWriter {
+	return &timeRotateWriter{
+		file:      file,
+		newLogger: newLogger,
+		now:       time.Now,
+	}
+}
+
+func (w *timeRotateWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := w.now()
+	if w.current == nil || !now.Before(w.next) {
+		if w.current != nil {
+			// 旧文件关闭失败不影响写入新文件
+			_ = w.current.Close()
+		}
+		start := w.file.rotation.start(now)
+		w.next = w.file.rotation.next(start)
+		w.current = w.newLogger(w.file.filenameAt(start))
+	}
+	return w.current.Write(p)
+}
+
+func (w *timeRotateWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.current == nil {
+		return nil
+	}
+	err := w.current.Close()
+	w.current = nil
+	return err
+}
@@ -0,0 +1,195 @@
+package log
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+func TestTimeRotation_Boundaries(t *testing.T) {
+	shanghai := time.FixedZone("CST", 8*3600)
+	now := time.Date(2024, 3, 5, 23, 40, 10, 0, shanghai)
+
+	tests := []struct {
+		period time.Duration
+		start  time.Time
+		next   time.Time
+		stamp  string
+	}{
+		{RotateDaily, time.Date(2024, 3, 5, 0, 0, 0, 0, shanghai), time.Date(2024, 3, 6, 0, 0, 0, 0, shanghai), "2024-03-05"},
+		{RotateHourly, time.Date(2024, 3, 5, 23, 0, 0, 0, shanghai), time.Date(2024, 3, 6, 0, 0, 0, 0, shanghai), "2024-03-05-23"},
+		{15 * time.Minute, time.Date(2024, 3, 5, 23, 30, 0, 0, shanghai), time.Date(2024, 3, 5, 23, 45, 0, 0, shanghai), "2024-03-05-23-30"},
+		// 不能整除一天的周期在零点截断
+		{7 * time.Hour, time.Date(2024, 3, 5, 21, 0, 0, 0, shanghai), time.Date(2024, 3, 6, 0, 0, 0, 0, shanghai), "2024-03-05-21"},
+		{7 * RotateDaily, time.Date(2024, 2, 29, 0, 0, 0, 0, shanghai), time.Date(2024, 3, 7, 0, 0, 0, 0, shanghai), "2024-02-29"},
+	}
+	for _, tt := range tests {
+		r := &timeRotation{period: tt.period, location: shanghai}
+		start := r.start(now)
+		if !start.Equal(tt.start) {
+			t.Errorf("%s: start = %v, want %v", tt.period, start, tt.start)
+		}
+		if next := r.next(start); !next.Equal(tt.next) {
+			t.Errorf("%s: next = %v, want %v", tt.period, next, tt.next)
+		}
+		if stamp := start.Format(r.layout()); stamp != tt.stamp {
+			t.Errorf("%s: stamp = %s, want %s", tt.period, stamp, tt.stamp)
+		}
+	}
+
+	// 同一时刻在不同时区属于不同的一天
+	later := now.Add(time.Hour)
+	daily := &timeRotation{period: RotateDaily, location: shanghai}
+	if stamp := daily.start(later).Format(daily.layout()); stamp != "2024-03-06" {
+		t.Errorf("shanghai stamp = %s, want 2024-03-06", stamp)
+	}
+	utc := &timeRotation{period: RotateDaily, location: time.UTC}
+	if stamp := utc.start(later).Format(utc.layout()); stamp != "2024-03-05" {
+		t.Errorf("utc stamp = %s, want 2024-03-05", stamp)
+	}
+}
+
+func TestStampFilename(t *testing.T) {
+	tests := []struct {
+		raw, stamp, level, want string
+	}{
+		{"./app.log", "2024-03-05", "", "./app_2024-03-05.log"},
+		{"./app.log", "2024-03-05", "warn", "./app_2024-03-05_warn.log"},
+		{"/var/log/app/app.log", "2024-03-05-23", "", "/var/log/app/app_2024-03-05-23.log"},
+		{"logs/app", "2024-03-05", "", "logs/app_2024-03-05"},
+		{"app.log", "", "", "app.log"},
+		{"", "2024-03-05", "", ""},
+	}
+	for _, tt := range tests {
+		if got := stampFilename(tt.raw, tt.stamp, tt.level); got != tt.want {
+			t.Errorf("stampFilename(%q, %q, %q) = %q, want %q", tt.raw, tt.stamp, tt.level, got, tt.want)
+		}
+	}
+}
+
+func TestTimeRotateWriter(t *testing.T) {
+	dir := t.TempDir()
+	clock := time.Date(2024, 3, 5, 23, 59, 0, 0, time.UTC)
+	file := logFile{
+		filename: filepath.Join(dir, "app.log"),
+		level:    "warn",
+		rotation: &timeRotation{period: RotateDaily, location: time.UTC},
+	}
+	writer := newTimeRotateWriter(file, New().newLumberjackLogger)
+	writer.now = func() time.Time { return clock }
+	defer writer.Close()
+
+	if _, err := writer.Write([]byte("monday\n")); err != nil {
+		t.Fatal(err)
+	}
+	clock = clock.Add(2 * time.Minute)
+	if _, err := writer.Write([]byte("tuesday\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]string{
+		"app_2024-03-05_warn.log": "monday",
+		"app_2024-03-06_warn.log": "tuesday",
+	} {
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.TrimSpace(string(content)) != want {
+			t.Errorf("%s = %q, want %q", name, content, want)
+		}
+	}
+}
+
+func TestConfig_WithTimeRotation(t *testing.T) {
+	dir := t.TempDir()
+	logger, err := New().
+		WithFilename(filepath.Join(dir, "app.log")).
+		WithWarnLog(filepath.Join(dir, "warn.log")).
+		WithTimeRotation(RotateHourly, time.UTC).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Warn("hourly rotated")
+
+	stamp := time.Now().UTC().Format("2006-01-02-15")
+	for _, name := range []string{"app_" + stamp + ".log", "warn_" + stamp + ".log"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("%s should be created: %v", name, err)
+		}
+	}
+
+	if _, err := New().WithFilename(filepath.Join(dir, "bad.log")).WithTimeRotation(36*time.Hour, nil).Build(); err == nil {
+		t.Error("Build() should reject a period of 36h")
+	}
+}
+
+func TestConfig_WithFilenameKeepsName(t *testing.T) {
+	c := New()
+	if files := c.logFiles(zapcore.Level(c.rollingConfig.level)); files[0].rotation == nil {
+		t.Error("default filename should be rotated daily")
+	}
+	c.WithFilename("app.log").WithWarnLog("")
+	files := c.logFiles(zapcore.Level(c.rollingConfig.level))
+	if files[0].rotation != nil || files[0].filenameAt(time.Now()) != "app.log" {
+		t.Errorf("explicit filename should be kept as is, got %+v", files[0])
+	}
+	today := time.Now().Format("2006-01-02")
+	if got := files[1].filenameAt(time.Now()); got != "./app_"+today+"_warn.log" {
+		t.Errorf("default warn filename = %s", got)
+	}
+}
+
+func TestConfig_WithRotation(t *testing.T) {
+	dir := t.TempDir()
+	c := New().
+		WithFilename(filepath.Join(dir, "app.log")).
+		WithWarnLog(filepath.Join(dir, "warn.log")).
+		WithRotation(RotationPolicy{MaxSize: 1, MaxAge: 3, MaxBackups: 2, Compress: true, LocalTime: true})
+
+	for _, file := range c.logFiles(zapcore.Level(c.rollingConfig.level)) {
+		writer, ok := c.newFileWriter(file).(*lumberjack.Logger)
+		if !ok {
+			t.Fatalf("unexpected writer for %s", file.filename)
+		}
+		if writer.MaxSize != 1 || writer.MaxAge != 3 || writer.MaxBackups != 2 || !writer.Compress || !writer.LocalTime {
+			t.Errorf("%s: policy not applied: %+v", file.field, writer)
+		}
+	}
+
+	logger := c.WithoutStdout().Init()
+	payload := strings.Repeat("x", 600*1024)
+	logger.Info(payload)
+	logger.Info(payload)
+
+	// lumberjack 在后台压缩旧文件
+	waitFor(t, func() bool {
+		matches, _ := filepath.Glob(filepath.Join(dir, "app-*.log.gz"))
+		return len(matches) == 1
+	})
+
+	if _, err := New().WithRotation(RotationPolicy{MaxBackups: -1}).Build(); err == nil {
+		t.Error("Build() should reject negative MaxBackups")
+	}
+}
+
+func TestConfig_Rotation(t *testing.T) {
+	// 从当前策略开始修改时保留默认的大小和保留天数
+	c := New()
+	policy := c.Rotation()
+	policy.Compress = true
+	if got := c.WithRotation(policy).Rotation(); got != (RotationPolicy{MaxSize: 500, MaxAge: 30, Compress: true}) {
+		t.Errorf("Rotation() = %+v", got)
+	}
+
+	// 整体替换时未设置的字段为零值
+	if got := c.WithRotation(RotationPolicy{Compress: true}).Rotation(); got != (RotationPolicy{Compress: true}) {
+		t.Errorf("Rotation() = %+v", got)
+	}
+}
@@ -0,0 +1,434 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/multierr"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+const (
+	// EncodingJSON 与日志文件相同的 JSON 格式
+	EncodingJSON = "json"
+	// EncodingConsole 与控制台相同的文本格式，不带颜色
+	EncodingConsole = "console"
+)
+
+// Sink 日志的输出目标，由 RegisterSink 注册的工厂按 URL 创建，Logger.Close 时关闭
+type Sink interface {
+	zapcore.WriteSyncer
+	io.Closer
+}
+
+// CoreSink 需要完整日志条目的 Sink，如按级别映射 syslog 严重级别、将字段写为独立的字段，
+// 实现该接口的 Sink 由 Core 创建写入自身的 core，enc 为 WithSink 指定的编码，
+// fields 为 WithFields 设置的字段，由 Sink 自行决定如何输出
+type CoreSink interface {
+	Sink
+	Core(enc zapcore.Encoder, level zapcore.LevelEnabler, fields map[string]any) zapcore.Core
+}
+
+// SinkFactory 按 URL 创建 Sink，URL 的 scheme 已与注册时的 scheme 匹配
+type SinkFactory func(u *url.URL) (Sink, error)
+
+// sinkRegistry 记录 scheme 到工厂的映射，scheme 不区分大小写
+var sinkRegistry = struct {
+	sync.RWMutex
+	factories map[string]SinkFactory
+}{factories: map[string]SinkFactory{
+	"file":   newFileSink,
+	"stdout": newStdSink(os.Stdout),
+	"stderr": newStdSink(os.Stderr),
+	"tcp":    newNetSink,
+	"udp":    newNetSink,
+	"unix":   newNetSink,
+}}
+
+// RegisterSink 注册 scheme 对应的 Sink 工厂，之后可通过 WithSink 使用该 scheme 的 URL，
+// 同一 scheme 只能注册一次
+func RegisterSink(scheme string, factory SinkFactory) error {
+	scheme = strings.ToLower(scheme)
+	if scheme == "" {
+		return errors.New("empty sink scheme")
+	}
+	if factory == nil {
+		return fmt.Errorf("nil factory for sink scheme %q", scheme)
+	}
+
+	sinkRegistry.Lock()
+	defer sinkRegistry.Unlock()
+	if _, ok := sinkRegistry.factories[scheme]; ok {
+		return fmt.Errorf("sink scheme %q already registered", scheme)
+	}
+	sinkRegistry.factories[scheme] = factory
+	return nil
+}
+
+func lookupSinkFactory(scheme string) (SinkFactory, bool) {
+	sinkRegistry.RLock()
+	defer sinkRegistry.RUnlock()
+	factory, ok := sinkRegistry.factories[strings.ToLower(scheme)]
+	return factory, ok
+}
+
+// sinkConfig WithSink 添加的输出，级别固定，不随 SetLevel 变化
+type sinkConfig struct {
+	url      *url.URL
+	level    Level
+	encoding string
+}
+
+// WithSink 添加按 URL 创建的输出，内置的 scheme 有 file、stdout、stderr、tcp、udp 和 unix，
+// 其他 scheme 需先通过 RegisterSink 注册。encoding 为 EncodingJSON 或 EncodingConsole，为空时使用 EncodingJSON。
+// URL 有误时 Init 会忽略该输出，Build 会返回错误
+func (c *Config) WithSink(rawURL string, level Level, encoding string) *Config {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		c.addError("sink", urlParseError(err))
+		return c
+	}
+	if encoding == "" {
+		encoding = EncodingJSON
+	}
+	c.sinks = append(c.sinks, sinkConfig{
+		url:      u,
+		level:    level,
+		encoding: encoding,
+	})
+	return c
+}
+
+func (s sinkConfig) field() string {
+	return "sink " + redactURL(s.url)
+}
+
+// sinkSecretParams 错误信息中需要隐藏的 URL 参数
+var sinkSecretParams = []string{"api_key", "password", "secret", "token"}
+
+// redactURL 隐藏 URL 中的密码以及 api_key 等参数，避免凭据随 ConfigError 写入启动日志
+func redactURL(u *url.URL) string {
+	redacted := *u
+	query := redacted.Query()
+	changed := false
+	for _, name := range sinkSecretParams {
+		if query.Has(name) {
+			query.Set(name, "xxxxx")
+			changed = true
+		}
+	}
+	if changed {
+		redacted.RawQuery = query.Encode()
+	}
+	return redacted.Redacted()
+}
+
+// urlParseError url.Parse 的错误包含完整的 URL，只保留原因
+func urlParseError(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
+
+func (s sinkConfig) validate() error {
+	if _, ok := lookupSinkFactory(s.url.Scheme); !ok {
+		return fmt.Errorf("unknown scheme %q", s.url.Scheme)
+	}
+	if !validLevel(s.level) {
+		return fmt.Errorf("invalid level %d", s.level)
+	}
+	if s.encoding != EncodingJSON && s.encoding != EncodingConsole {
+		return fmt.Errorf("unknown encoding %q, expect %s or %s", s.encoding, EncodingJSON, EncodingConsole)
+	}
+	return nil
+}
+
+// openSinks 创建所有有效的输出，无法创建的输出被跳过并在错误中返回
+func (c *Config) openSinks() ([]zapcore.Core, []Sink, error) {
+	var (
+		cores []zapcore.Core
+		sinks []Sink
+		err   error
+	)
+	for _, s := range c.sinks {
+		if validateErr := s.validate(); validateErr != nil {
+			err = multierr.Append(err, &ConfigError{Field: s.field(), Err: validateErr})
+			continue
+		}
+		factory, _ := lookupSinkFactory(s.url.Scheme)
+		sink, openErr := factory(s.url)
+		if openErr != nil {
+			err = multierr.Append(err, &ConfigError{Field: s.field(), Err: openErr})
+			continue
+		}
+		if coreSink, ok := sink.(CoreSink); ok {
+			cores = append(cores, coreSink.Core(c.sinkEncoder(s.encoding), zapcore.Level(s.level), c.staticFields()))
+		} else {
+			core := zapcore.NewCore(c.sinkEncoder(s.encoding), zapcore.Lock(sink), zapcore.Level(s.level))
+			cores = append(cores, c.setFields(core))
+		}
+		sinks = append(sinks, sink)
+	}
+	return cores, sinks, err
+}
+
+// staticFields 返回 WithFields 设置的字段，不包含 HumanTime
+func (c *Config) staticFields() map[string]any {
+	fields := make(map[string]any, len(c.fieldsConfig.fields))
+	for k, v := range c.fieldsConfig.fields {
+		if k != HumanTime {
+			fields[k] = v
+		}
+	}
+	return fields
+}
+
+func (c *Config) sinkEncoder(encoding string) zapcore.Encoder {
+	if encoding == EncodingConsole {
+		return zapcore.NewConsoleEncoder(consoleEncoderConfig())
+	}
+	return zapcore.NewJSONEncoder(c.fileEncoderConfig())
+}
+
+// newFileSink 支持 file:///var/log/app.log 和 file://logs/app.log 形式的 URL，
+// 可通过 max_size、max_age、max_backups、compress 参数设置切分策略
+func newFileSink(u *url.URL) (Sink, error) {
+	filename := u.Host + u.Path
+	if u.Opaque != "" {
+		filename = u.Opaque
+	}
+	if filename == "" {
+		return nil, errors.New("empty filename")
+	}
+
+	logger := &lumberjack.Logger{Filename: filename}
+	query := u.Query()
+	for name, target := range map[string]*int{
+		"max_size":    &logger.MaxSize,
+		"max_age":     &logger.MaxAge,
+		"max_backups": &logger.MaxBackups,
+	} {
+		if value := query.Get(name); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid %s %q", name, value)
+			}
+			*target = n
+		}
+	}
+	if value := query.Get("compress"); value != "" {
+		compress, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid compress %q", value)
+		}
+		logger.Compress = compress
+	}
+
+	// 与 Build 一致，提前打开文件以暴露权限、目录等问题
+	if _, err := logger.Write(nil); err != nil {
+		return nil, err
+	}
+	return fileSink{logger}, nil
+}
+
+type fileSink struct {
+	*lumberjack.Logger
+}
+
+func (fileSink) Sync() error {
+	return nil
+}
+
+// newStdSink 标准输出不会被关闭
+func newStdSink(f *os.File) SinkFactory {
+	return func(*url.URL) (Sink, error) {
+		return stdSink{consoleSyncer(f)}, nil
+	}
+}
+
+type stdSink struct {
+	zapcore.WriteSyncer
+}
+
+func (stdSink) Close() error {
+	return nil
+}
+
+const (
+	// DefaultSinkDialTimeout 网络输出建立连接的超时时间
+	DefaultSinkDialTimeout = 5 * time.Second
+	// DefaultSinkWriteTimeout 网络输出单次写入的超时时间，对端不读取时不会一直阻塞
+	DefaultSinkWriteTimeout = time.Second
+
+	// sinkMinRedialBackoff、sinkMaxRedialBackoff 连接失败后重新连接的退避时间
+	sinkMinRedialBackoff = 500 * time.Millisecond
+	sinkMaxRedialBackoff = 30 * time.Second
+)
+
+// errSinkUnavailable 连接失败后的退避期间，或其他写入正在连接时丢弃日志
+var errSinkUnavailable = errors.New("sink unavailable, entry dropped")
+
+// newNetSink 支持 tcp://host:port、udp://host:port 和 unix:///path/to/socket，
+// 连接在首次写入时建立，写入失败后在下次写入时重新连接
+func newNetSink(u *url.URL) (Sink, error) {
+	network := strings.ToLower(u.Scheme)
+	address := u.Host
+	if network == "unix" {
+		address = u.Path
+	}
+	if address == "" {
+		return nil, errors.New("empty address")
+	}
+	return &netSink{redialer{dial: func() (net.Conn, error) {
+		return net.DialTimeout(network, address, DefaultSinkDialTimeout)
+	}}}, nil
+}
+
+type netSink struct {
+	conn redialer
+}
+
+func (s *netSink) Write(p []byte) (int, error) {
+	return s.conn.write(func(conn net.Conn) (int, error) {
+		return conn.Write(p)
+	})
+}
+
+func (s *netSink) Sync() error {
+	return nil
+}
+
+func (s *netSink) Close() error {
+	return s.conn.close()
+}
+
+// redialer 管理网络输出的连接，连接在写入时建立，写入失败后关闭，下次写入时重新连接。
+// 建立连接时不持有锁，连接失败后按指数退避，退避期间以及其他写入正在连接时直接返回
+// errSinkUnavailable，目标不可达时日志不会逐条等待连接超时
+type redialer struct {
+	dial func() (net.Conn, error)
+
+	mu      sync.Mutex
+	conn    net.Conn
+	dialing bool
+	// closes 每次 close 加一，close 前发起的连接完成后直接关闭
+	closes  int
+	backoff time.Duration
+	retryAt time.Time
+	dialErr error
+}
+
+// write 在连接上调用 write，每次写入设置 DefaultSinkWriteTimeout 的超时
+func (r *redialer) write(write func(conn net.Conn) (int, error)) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.conn == nil {
+		if err := r.connect(); err != nil {
+			return 0, err
+		}
+	}
+	_ = r.conn.SetWriteDeadline(time.Now().Add(DefaultSinkWriteTimeout))
+	n, err := write(r.conn)
+	if err != nil {
+		_ = r.conn.Close()
+		r.conn = nil
+	}
+	return n, err
+}
+
+// connect 建立连接，调用时持有 r.mu，连接期间释放
+func (r *redialer) connect() error {
+	if r.dialing {
+		return errSinkUnavailable
+	}
+	if time.Now().Before(r.retryAt) {
+		return fmt.Errorf("%w: %v", errSinkUnavailable, r.dialErr)
+	}
+
+	r.dialing = true
+	closes := r.closes
+	r.mu.Unlock()
+	conn, err := r.dial()
+	r.mu.Lock()
+	r.dialing = false
+
+	if err != nil {
+		if r.backoff *= 2; r.backoff == 0 {
+			r.backoff = sinkMinRedialBackoff
+		} else if r.backoff > sinkMaxRedialBackoff {
+			r.backoff = sinkMaxRedialBackoff
+		}
+		r.retryAt, r.dialErr = time.Now().Add(r.backoff), err
+		return err
+	}
+	if closes != r.closes {
+		_ = conn.Close()
+		return net.ErrClosed
+	}
+	r.conn, r.backoff, r.dialErr = conn, 0, nil
+	return nil
+}
+
+func (r *redialer) close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.closes++
+	if r.conn == nil {
+		return nil
+	}
+	err := r.conn.Close()
+	r.conn = nil
+	return err
+}
+
+// entryCore CoreSink 可复用的 core，负责级别判断与 With 字段的累积，每条日志交给 write 处理
+type entryCore struct {
+	zapcore.LevelEnabler
+	fields []zapcore.Field
+	write  func(entry zapcore.Entry, fields []zapcore.Field) error
+	sync   func() error
+}
+
+func (c *entryCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := *c
+	clone.fields = make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	clone.fields = append(clone.fields, c.fields...)
+	clone.fields = append(clone.fields, fields...)
+	return &clone
+}
+
+func (c *entryCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c *entryCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	if len(c.fields) != 0 {
+		all := make([]zapcore.Field, 0, len(c.fields)+len(fields))
+		all = append(all, c.fields...)
+		fields = append(all, fields...)
+	}
+	return c.write(entry, fields)
+}
+
+func (c *entryCore) Sync() error {
+	if c.sync == nil {
+		return nil
+	}
+	return c.sync()
+}
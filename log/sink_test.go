@@ -0,0 +1,219 @@
+package log
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/multierr"
+)
+
+type memorySink struct {
+	lines  []string
+	closed bool
+}
+
+func (s *memorySink) Write(p []byte) (int, error) {
+	s.lines = append(s.lines, string(p))
+	return len(p), nil
+}
+
+func (s *memorySink) Sync() error {
+	return nil
+}
+
+func (s *memorySink) Close() error {
+	s.closed = true
+	return nil
+}
+
+func TestRegisterSink(t *testing.T) {
+	t.Cleanup(func() {
+		sinkRegistry.Lock()
+		delete(sinkRegistry.factories, "memory-test")
+		sinkRegistry.Unlock()
+	})
+	sink := &memorySink{}
+	if err := RegisterSink("Memory-Test", func(u *url.URL) (Sink, error) {
+		if u.Host != "bucket" {
+			return nil, errors.New("unexpected host " + u.Host)
+		}
+		return sink, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterSink("memory-test", func(*url.URL) (Sink, error) { return nil, nil }); err == nil {
+		t.Error("RegisterSink should reject a duplicate scheme")
+	}
+	if err := RegisterSink("", func(*url.URL) (Sink, error) { return nil, nil }); err == nil {
+		t.Error("RegisterSink should reject an empty scheme")
+	}
+
+	logger, err := New().
+		WithFilename(filepath.Join(t.TempDir(), "app.log")).
+		WithoutStdout().
+		WithFields(map[string]any{"service": "sink-test"}).
+		WithSink("memory-test://bucket", WarnLevel, EncodingConsole).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("filtered")
+	logger.Warn("to memory")
+	if len(sink.lines) != 1 || !strings.Contains(sink.lines[0], "WARN\t") ||
+		!strings.Contains(sink.lines[0], "to memory") || !strings.Contains(sink.lines[0], `"service": "sink-test"`) {
+		t.Errorf("lines = %q", sink.lines)
+	}
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !sink.closed {
+		t.Error("Close should close sinks")
+	}
+}
+
+func TestFileSink(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "sink.log")
+	logger, err := New().
+		WithFilename(filepath.Join(dir, "app.log")).
+		WithoutStdout().
+		WithSink("file://"+filename+"?max_size=1&compress=true", ErrorLevel, "").
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer logger.Close()
+	logger.Warn("filtered")
+	logger.Error("to file sink")
+
+	entries := readLogEntries(t, filename)
+	if len(entries) != 1 || entries[0]["msg"] != "to file sink" || entries[0]["level"] != "error" {
+		t.Errorf("entries = %v", entries)
+	}
+}
+
+func TestNetSink(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	received := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		line, _ := bufio.NewReader(conn).ReadString('\n')
+		received <- line
+	}()
+
+	logger := New().
+		WithFilename(filepath.Join(t.TempDir(), "app.log")).
+		WithoutStdout().
+		WithSink("tcp://"+listener.Addr().String(), InfoLevel, EncodingJSON).
+		Init()
+	defer logger.Close()
+	logger.Info("over tcp")
+
+	select {
+	case line := <-received:
+		if !strings.Contains(line, `"msg":"over tcp"`) {
+			t.Errorf("received %s", line)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("tcp sink did not deliver the entry")
+	}
+}
+
+func TestNetSink_RedialBackoff(t *testing.T) {
+	var dials int
+	s := &netSink{redialer{dial: func() (net.Conn, error) {
+		dials++
+		return nil, errors.New("connection refused")
+	}}}
+
+	if _, err := s.Write([]byte("first\n")); err == nil || errors.Is(err, errSinkUnavailable) {
+		t.Errorf("first Write() = %v, want dial error", err)
+	}
+	// 退避期间不再连接，直接丢弃
+	if _, err := s.Write([]byte("second\n")); !errors.Is(err, errSinkUnavailable) {
+		t.Errorf("second Write() = %v, want errSinkUnavailable", err)
+	}
+	if dials != 1 {
+		t.Errorf("dials = %d, want 1", dials)
+	}
+}
+
+func TestNetSink_WriteTimeout(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	// 对端只接受连接，从不读取
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			defer conn.Close()
+			<-done
+		}
+	}()
+
+	u, _ := url.Parse("tcp://" + listener.Addr().String())
+	sink, err := newNetSink(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+
+	payload := make([]byte, 1<<20)
+	start := time.Now()
+	for i := 0; i < 64; i++ {
+		if _, err = sink.Write(payload); err != nil {
+			break
+		}
+	}
+	if err == nil || time.Since(start) > 5*time.Second {
+		t.Errorf("Write() = %v after %s, want a timeout", err, time.Since(start))
+	}
+}
+
+func TestWithSink_Errors(t *testing.T) {
+	dir := t.TempDir()
+	_, err := New().
+		WithFilename(filepath.Join(dir, "app.log")).
+		WithSink("nope://somewhere", InfoLevel, "").
+		WithSink("stdout://", InfoLevel, "xml").
+		WithSink("file://"+filepath.Join(dir, "missing", "dir", "x.log")+"?max_size=big", InfoLevel, "").
+		Build()
+	if err == nil {
+		t.Fatal("Build() should fail")
+	}
+	errs := multierr.Errors(err)
+	if len(errs) != 2 {
+		t.Fatalf("errors = %v", errs)
+	}
+	var configErr *ConfigError
+	if !errors.As(errs[0], &configErr) || configErr.Field != "sink nope://somewhere" {
+		t.Errorf("first error = %v", errs[0])
+	}
+
+	// Init 跳过无法创建的输出
+	logger := New().WithFilename(filepath.Join(dir, "init.log")).WithoutStdout().WithSink("nope://x", InfoLevel, "").Init()
+	defer logger.Close()
+	logger.Info("still works")
+	if content, _ := os.ReadFile(filepath.Join(dir, "init.log")); !strings.Contains(string(content), "still works") {
+		t.Errorf("file output should be kept, got %s", content)
+	}
+}
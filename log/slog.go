@@ -0,0 +1,252 @@
+//go:build go1.21
+
+package log
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// SlogHandler 返回写入 l 的 slog.Handler，与 l 共享级别、字段以及 warn、error 文件的拆分
+func (l *Logger) SlogHandler() slog.Handler {
+	return &slogHandler{core: l.zapLogger.Core()}
+}
+
+// slogHandler 将 slog.Record 转换为 zap 的日志条目写入日志器的 core，WithGroup 使用 zap.Namespace 实现
+type slogHandler struct {
+	core zapcore.Core
+}
+
+func (h *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.core.Enabled(zapLevel(level))
+}
+
+func (h *slogHandler) Handle(_ context.Context, record slog.Record) error {
+	entry := zapcore.Entry{
+		Level:   zapLevel(record.Level),
+		Time:    record.Time,
+		Message: record.Message,
+	}
+	if record.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{record.PC}).Next()
+		entry.Caller = zapcore.EntryCaller{
+			Defined:  true,
+			PC:       frame.PC,
+			File:     frame.File,
+			Line:     frame.Line,
+			Function: frame.Function,
+		}
+	}
+
+	ce := h.core.Check(entry, nil)
+	if ce == nil {
+		return nil
+	}
+	fields := make([]zap.Field, 0, record.NumAttrs())
+	record.Attrs(func(attr slog.Attr) bool {
+		fields = appendAttr(fields, attr)
+		return true
+	})
+	ce.Write(fields...)
+	return nil
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var fields []zap.Field
+	for _, attr := range attrs {
+		fields = appendAttr(fields, attr)
+	}
+	return &slogHandler{core: h.core.With(fields)}
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &slogHandler{core: h.core.With([]zap.Field{zap.Namespace(name)})}
+}
+
+// zapLevel 将 slog 的级别映射到最接近的 Level，低于 Debug 的级别映射为 TraceLevel
+func zapLevel(level slog.Level) zapcore.Level {
+	switch {
+	case level < slog.LevelDebug:
+		return zapcore.Level(TraceLevel)
+	case level < slog.LevelInfo:
+		return zapcore.DebugLevel
+	case level < slog.LevelWarn:
+		return zapcore.InfoLevel
+	case level < slog.LevelError:
+		return zapcore.WarnLevel
+	default:
+		return zapcore.ErrorLevel
+	}
+}
+
+// slogLevel 是 zapLevel 的逆映射，DPanic 及以上的级别映射为高于 Error 的级别
+func slogLevel(level zapcore.Level) slog.Level {
+	switch {
+	case level < zapcore.DebugLevel:
+		return slog.LevelDebug - 4
+	case level == zapcore.DebugLevel:
+		return slog.LevelDebug
+	case level == zapcore.InfoLevel:
+		return slog.LevelInfo
+	case level == zapcore.WarnLevel:
+		return slog.LevelWarn
+	default:
+		return slog.LevelError + slog.Level(level-zapcore.ErrorLevel)
+	}
+}
+
+// appendAttr 按 slog 的约定忽略空属性，键为空的分组展开到上一层
+func appendAttr(fields []zap.Field, attr slog.Attr) []zap.Field {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return fields
+	}
+	switch attr.Value.Kind() {
+	case slog.KindString:
+		return append(fields, zap.String(attr.Key, attr.Value.String()))
+	case slog.KindInt64:
+		return append(fields, zap.Int64(attr.Key, attr.Value.Int64()))
+	case slog.KindUint64:
+		return append(fields, zap.Uint64(attr.Key, attr.Value.Uint64()))
+	case slog.KindFloat64:
+		return append(fields, zap.Float64(attr.Key, attr.Value.Float64()))
+	case slog.KindBool:
+		return append(fields, zap.Bool(attr.Key, attr.Value.Bool()))
+	case slog.KindDuration:
+		return append(fields, zap.Duration(attr.Key, attr.Value.Duration()))
+	case slog.KindTime:
+		return append(fields, zap.Time(attr.Key, attr.Value.Time()))
+	case slog.KindGroup:
+		group := attr.Value.Group()
+		if len(group) == 0 {
+			return fields
+		}
+		if attr.Key == "" {
+			for _, groupAttr := range group {
+				fields = appendAttr(fields, groupAttr)
+			}
+			return fields
+		}
+		return append(fields, zap.Object(attr.Key, slogGroup(group)))
+	default:
+		if err, ok := attr.Value.Any().(error); ok {
+			return append(fields, zap.NamedError(attr.Key, err))
+		}
+		return append(fields, zap.Any(attr.Key, attr.Value.Any()))
+	}
+}
+
+type slogGroup []slog.Attr
+
+func (g slogGroup) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for _, field := range appendAttr(nil, slog.Attr{Value: slog.GroupValue(g...)}) {
+		field.AddTo(enc)
+	}
+	return nil
+}
+
+// NewFromSlog 创建写入 handler 的日志器，Logger 的级别与 handler 的 Enabled 同时生效，
+// 用于让使用 Logger 的代码与使用 slog 的代码输出到同一处
+func NewFromSlog(handler slog.Handler) *Logger {
+	stdoutLevel := zap.NewAtomicLevelAt(zapcore.Level(TraceLevel))
+	fileLevel := zap.NewAtomicLevelAt(zapcore.Level(TraceLevel))
+	logger := &Logger{
+		config:      New(),
+		stdoutLevel: stdoutLevel,
+		fileLevel:   fileLevel,
+	}
+	core := &slogCore{
+		handler: handler,
+		enabler: zap.LevelEnablerFunc(func(level zapcore.Level) bool {
+			return level >= zapcore.Level(logger.Level())
+		}),
+	}
+	logger.zapLogger = zap.New(
+		core,
+		zap.AddCaller(),
+		zap.AddCallerSkip(1),
+	)
+	return logger
+}
+
+// slogCore 将 zap 的日志条目转换为 slog.Record 交给 handler
+type slogCore struct {
+	handler slog.Handler
+	enabler zapcore.LevelEnabler
+}
+
+func (c *slogCore) Enabled(level zapcore.Level) bool {
+	return c.enabler.Enabled(level) && c.handler.Enabled(context.Background(), slogLevel(level))
+}
+
+func (c *slogCore) With(fields []zapcore.Field) zapcore.Core {
+	handler := c.handler
+	start := 0
+	for i, field := range fields {
+		if field.Type == zapcore.NamespaceType {
+			handler = handler.WithAttrs(fieldsToAttrs(fields[start:i])).WithGroup(field.Key)
+			start = i + 1
+		}
+	}
+	return &slogCore{
+		handler: handler.WithAttrs(fieldsToAttrs(fields[start:])),
+		enabler: c.enabler,
+	}
+}
+
+func (c *slogCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c *slogCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	// zap 记录的是调用指令所在的 PC，slog 按返回地址解析，需要加一
+	var pc uintptr
+	if entry.Caller.Defined && entry.Caller.PC != 0 {
+		pc = entry.Caller.PC + 1
+	}
+	t := entry.Time
+	if t.IsZero() {
+		t = time.Now()
+	}
+	record := slog.NewRecord(t, slogLevel(entry.Level), entry.Message, pc)
+	if entry.LoggerName != "" {
+		record.AddAttrs(slog.String("logger", entry.LoggerName))
+	}
+	record.AddAttrs(fieldsToAttrs(fields)...)
+	return c.handler.Handle(context.Background(), record)
+}
+
+func (c *slogCore) Sync() error {
+	return nil
+}
+
+// fieldsToAttrs 借助 MapObjectEncoder 将 zap 字段统一转换为 slog 属性，zap.Namespace 之后的字段放入同名分组
+func fieldsToAttrs(fields []zapcore.Field) []slog.Attr {
+	if len(fields) == 0 {
+		return nil
+	}
+	enc := zapcore.NewMapObjectEncoder()
+	attrs := make([]slog.Attr, 0, len(fields))
+	for i, field := range fields {
+		if field.Type == zapcore.NamespaceType {
+			return append(attrs, slog.Attr{Key: field.Key, Value: slog.GroupValue(fieldsToAttrs(fields[i+1:])...)})
+		}
+		field.AddTo(enc)
+		if value, ok := enc.Fields[field.Key]; ok {
+			attrs = append(attrs, slog.Any(field.Key, value))
+			delete(enc.Fields, field.Key)
+		}
+	}
+	return attrs
+}
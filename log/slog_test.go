@@ -0,0 +1,101 @@
+//go:build go1.21
+
+package log
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestLogger_SlogHandler(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "slog.log")
+	warnFilename := filepath.Join(dir, "slog_warn.log")
+	logger := New().
+		WithFilename(filename).
+		WithWarnLog(warnFilename).
+		WithoutStdout().
+		WithLevel(InfoLevel).
+		WithFields(map[string]any{"service": "slog-test"}).
+		Init()
+	defer logger.Close()
+
+	s := slog.New(logger.SlogHandler()).With("request_id", "r-1")
+	s.Debug("filtered")
+	s.WithGroup("req").Info("slog info", "method", "GET", slog.Group("user", "id", 42))
+	s.Warn("slog warn", "err", errors.New("boom"))
+
+	entries := readLogEntries(t, filename)
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries: %v", len(entries), entries)
+	}
+	info := entries[0]
+	if info["msg"] != "slog info" || info["level"] != "info" || info["service"] != "slog-test" || info["request_id"] != "r-1" {
+		t.Errorf("info entry = %v", info)
+	}
+	req, _ := info["req"].(map[string]any)
+	user, _ := req["user"].(map[string]any)
+	if req["method"] != "GET" || user["id"] != float64(42) {
+		t.Errorf("group fields = %v", info["req"])
+	}
+	if caller, _ := info["caller"].(string); !strings.HasPrefix(caller, "log/slog_test.go") {
+		t.Errorf("caller = %v", info["caller"])
+	}
+
+	warns := readLogEntries(t, warnFilename)
+	if len(warns) != 1 || warns[0]["msg"] != "slog warn" || warns[0]["err"] != "boom" {
+		t.Errorf("warn entries = %v", warns)
+	}
+
+	logger.SetLevel(DebugLevel)
+	if !s.Enabled(context.Background(), slog.LevelDebug) {
+		t.Error("handler should follow the logger level")
+	}
+}
+
+func TestNewFromSlog(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewFromSlog(slog.NewJSONHandler(&buf, &slog.HandlerOptions{AddSource: true, Level: slog.LevelInfo}))
+
+	logger.Debug("filtered by handler")
+	logger.With(zap.String("request_id", "r-1")).Named("http").Info("from noop", zap.Int("status", 200))
+	logger.SetLevel(ErrorLevel)
+	logger.Warn("filtered by logger")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("got %d lines: %s", len(lines), buf.String())
+	}
+	var entry map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry["msg"] != "from noop" || entry["level"] != "INFO" || entry["request_id"] != "r-1" ||
+		entry["logger"] != "http" || entry["status"] != float64(200) {
+		t.Errorf("entry = %v", entry)
+	}
+	source, _ := entry["source"].(map[string]any)
+	if file, _ := source["file"].(string); !strings.HasSuffix(file, "slog_test.go") {
+		t.Errorf("source = %v", entry["source"])
+	}
+	if err := logger.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSlogLevelMapping(t *testing.T) {
+	for _, level := range []Level{TraceLevel, DebugLevel, InfoLevel, WarnLevel, ErrorLevel} {
+		if got := Level(zapLevel(slogLevel(zapcore.Level(level)))); got != level {
+			t.Errorf("round trip of %v got %v", level, got)
+		}
+	}
+}
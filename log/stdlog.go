@@ -0,0 +1,54 @@
+package log
+
+import (
+	"bytes"
+	stdlog "log"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// stdLogCallerSkip 标准库 log 调用 Write 时经过的栈帧，Printf -> output -> Write
+const stdLogCallerSkip = 2
+
+// StdLogger 返回以 level 写入 l 的标准库 *log.Logger，可用于 http.Server.ErrorLog 等只接受标准库日志器的地方，
+// 日志中的 caller 指向调用标准库日志器的位置
+func (l *Logger) StdLogger(level Level) *stdlog.Logger {
+	return stdlog.New(l.stdLogWriter(level), "", 0)
+}
+
+// RedirectStdLog 将标准库 log 包的全局日志器以 level 重定向到 l，返回恢复原有输出、前缀和 flags 的函数
+func (l *Logger) RedirectStdLog(level Level) func() {
+	flags := stdlog.Flags()
+	prefix := stdlog.Prefix()
+	writer := stdlog.Writer()
+
+	stdlog.SetFlags(0)
+	stdlog.SetPrefix("")
+	stdlog.SetOutput(l.stdLogWriter(level))
+	return func() {
+		stdlog.SetFlags(flags)
+		stdlog.SetPrefix(prefix)
+		stdlog.SetOutput(writer)
+	}
+}
+
+func (l *Logger) stdLogWriter(level Level) *stdLogWriter {
+	return &stdLogWriter{
+		logger: l.zapLogger.WithOptions(zap.AddCallerSkip(stdLogCallerSkip)),
+		level:  zapcore.Level(level),
+	}
+}
+
+// stdLogWriter 将标准库日志器的每次输出作为一条日志
+type stdLogWriter struct {
+	logger *zap.Logger
+	level  zapcore.Level
+}
+
+func (w *stdLogWriter) Write(p []byte) (int, error) {
+	if ce := w.logger.Check(w.level, string(bytes.TrimSuffix(p, []byte("\n")))); ce != nil {
+		ce.Write()
+	}
+	return len(p), nil
+}
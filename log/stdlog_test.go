@@ -0,0 +1,46 @@
+package log
+
+import (
+	stdlog "log"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLogger_StdLogger(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "stdlog.log")
+	logger := New().
+		WithFilename(filename).
+		WithoutStdout().
+		WithLevel(InfoLevel).
+		WithFields(map[string]any{"service": "stdlog-test"}).
+		Init()
+	defer logger.Close()
+
+	logger.StdLogger(WarnLevel).Printf("from std logger %d", 1)
+	logger.StdLogger(DebugLevel).Print("filtered")
+
+	restore := logger.RedirectStdLog(ErrorLevel)
+	stdlog.Println("from std log package")
+	restore()
+
+	entries := readLogEntries(t, filename)
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries: %v", len(entries), entries)
+	}
+	for i, want := range []struct{ msg, level string }{
+		{"from std logger 1", "warn"},
+		{"from std log package", "error"},
+	} {
+		entry := entries[i]
+		if entry["msg"] != want.msg || entry["level"] != want.level || entry["service"] != "stdlog-test" {
+			t.Errorf("entry %d = %v", i, entry)
+		}
+		if caller, _ := entry["caller"].(string); !strings.HasPrefix(caller, "log/stdlog_test.go") {
+			t.Errorf("entry %d caller = %v", i, entry["caller"])
+		}
+	}
+	if stdlog.Flags() != stdlog.LstdFlags || stdlog.Writer() == nil {
+		t.Error("restore should bring back the standard logger settings")
+	}
+}
@@ -0,0 +1,337 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// SyslogRFC5424 RFC 5424 格式，WithFields 设置的字段写入 structured-data
+	SyslogRFC5424 = "rfc5424"
+	// SyslogRFC3164 传统的 BSD syslog 格式，没有 structured-data，WithFields 设置的字段写入日志内容
+	SyslogRFC3164 = "rfc3164"
+
+	// syslogSDID structured-data 的 SD-ID，32473 是 IANA 保留给文档示例的企业编号
+	syslogSDID = "noop@32473"
+)
+
+// syslogFacilities RFC 5424 定义的 facility
+var syslogFacilities = map[string]int{
+	"kern": 0, "user": 1, "mail": 2, "daemon": 3, "auth": 4, "syslog": 5, "lpr": 6, "news": 7,
+	"uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11,
+	"local0": 16, "local1": 17, "local2": 18, "local3": 19,
+	"local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+// syslogLocalAddresses 未指定地址时依次尝试的本地 syslog socket
+var syslogLocalAddresses = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+func init() {
+	for _, scheme := range []string{"syslog", "syslog+udp", "syslog+tcp", "syslog+unix"} {
+		if err := RegisterSink(scheme, newSyslogSink); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// SyslogOptions WithSyslog 的选项，零值表示以 RFC 5424 格式、user facility 写入本地 /dev/log
+type SyslogOptions struct {
+	// Network 为 udp、tcp 或 unix，为空时写入本地 syslog socket
+	Network string
+	// Address udp、tcp 的 host:port 或 unix socket 的路径
+	Address string
+	// Facility 如 user、daemon、local0，为空时使用 user
+	Facility string
+	// AppName 为空时使用进程名
+	AppName string
+	// Format 为 SyslogRFC5424 或 SyslogRFC3164，为空时使用 SyslogRFC5424
+	Format string
+	// Level 写入 syslog 的最低级别
+	Level Level
+	// Encoding 日志内容的编码，为空时使用 EncodingJSON
+	Encoding string
+}
+
+// WithSyslog 添加 syslog 输出，Level 映射为 syslog 严重级别，tcp 使用 octet-counting 分帧，
+// 连接断开后在下次写入时重新连接。等价于使用 syslog、syslog+udp、syslog+tcp、syslog+unix URL 调用 WithSink，
+// 如 syslog+udp://127.0.0.1:514?facility=local0&app=api&format=rfc3164
+func (c *Config) WithSyslog(opts SyslogOptions) *Config {
+	return c.WithSink(opts.url(), opts.Level, opts.Encoding)
+}
+
+func (o SyslogOptions) url() string {
+	u := url.URL{Scheme: "syslog"}
+	if o.Network != "" {
+		u.Scheme += "+" + o.Network
+	}
+	if o.Network == "unix" {
+		u.Path = o.Address
+	} else {
+		u.Host = o.Address
+	}
+	query := url.Values{}
+	if o.Facility != "" {
+		query.Set("facility", o.Facility)
+	}
+	if o.AppName != "" {
+		query.Set("app", o.AppName)
+	}
+	if o.Format != "" {
+		query.Set("format", o.Format)
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+func newSyslogSink(u *url.URL) (Sink, error) {
+	s := &syslogSink{
+		format:  SyslogRFC5424,
+		appName: filepath.Base(os.Args[0]),
+		pid:     os.Getpid(),
+	}
+	s.hostname, _ = os.Hostname()
+	s.conn.dial = s.dial
+
+	switch network := strings.TrimPrefix(strings.ToLower(u.Scheme), "syslog"); network {
+	case "":
+	case "+udp", "+tcp":
+		s.network, s.address = network[1:], u.Host
+	case "+unix":
+		s.network, s.address = "unix", u.Path
+	}
+	if s.network != "" && s.address == "" {
+		return nil, errors.New("empty address")
+	}
+
+	query := u.Query()
+	facility := query.Get("facility")
+	if facility == "" {
+		facility = "user"
+	}
+	code, ok := syslogFacilities[strings.ToLower(facility)]
+	if !ok {
+		return nil, fmt.Errorf("unknown facility %q", facility)
+	}
+	s.facility = code
+	if app := query.Get("app"); app != "" {
+		s.appName = app
+	}
+	if format := strings.ToLower(query.Get("format")); format != "" {
+		if format != SyslogRFC5424 && format != SyslogRFC3164 {
+			return nil, fmt.Errorf("unknown format %q, expect %s or %s", format, SyslogRFC5424, SyslogRFC3164)
+		}
+		s.format = format
+	}
+	return s, nil
+}
+
+// syslogSink 在首次写入时建立连接，写入失败时重新连接并重试一次，连接失败后按退避时间重新连接
+type syslogSink struct {
+	network  string
+	address  string
+	conn     redialer
+	format   string
+	facility int
+	appName  string
+	hostname string
+	pid      int
+}
+
+func (s *syslogSink) Core(enc zapcore.Encoder, level zapcore.LevelEnabler, fields map[string]any) zapcore.Core {
+	structuredData := syslogStructuredData(fields)
+	core := &entryCore{
+		LevelEnabler: level,
+		write: func(entry zapcore.Entry, fields []zapcore.Field) error {
+			body, err := enc.EncodeEntry(entry, fields)
+			if err != nil {
+				return err
+			}
+			defer body.Free()
+			_, err = s.Write(s.message(entry, structuredData, bytes.TrimRight(body.Bytes(), "\n")))
+			return err
+		},
+	}
+	if s.format != SyslogRFC3164 || len(fields) == 0 {
+		return core
+	}
+
+	// RFC 3164 没有 structured-data，与普通输出一样把字段写入日志内容
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	staticFields := make([]zapcore.Field, 0, len(names))
+	for _, name := range names {
+		staticFields = append(staticFields, zap.Any(name, fields[name]))
+	}
+	return core.With(staticFields)
+}
+
+// message 按 format 生成完整的 syslog 消息
+func (s *syslogSink) message(entry zapcore.Entry, structuredData string, body []byte) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "<%d>", s.facility*8+syslogSeverity(entry.Level))
+	if s.format == SyslogRFC3164 {
+		buf.WriteString(entry.Time.Format(time.Stamp))
+		// 本地 socket 由 syslog 守护进程补充主机名
+		if s.network == "udp" || s.network == "tcp" {
+			buf.WriteString(" " + syslogHeaderField(s.hostname, 255))
+		}
+		fmt.Fprintf(&buf, " %s[%d]: ", syslogHeaderField(s.appName, 48), s.pid)
+	} else {
+		fmt.Fprintf(&buf, "1 %s %s %s %d %s %s ",
+			entry.Time.Format("2006-01-02T15:04:05.000000Z07:00"),
+			syslogHeaderField(s.hostname, 255),
+			syslogHeaderField(s.appName, 48),
+			s.pid,
+			syslogHeaderField(entry.LoggerName, 32),
+			structuredData,
+		)
+	}
+	buf.Write(body)
+	return buf.Bytes()
+}
+
+// Write 将 p 作为一条完整的 syslog 消息发送，已有连接写入失败时重新连接并重试一次
+func (s *syslogSink) Write(p []byte) (int, error) {
+	write := func(conn net.Conn) (int, error) {
+		if _, err := conn.Write(s.frame(conn, p)); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+	n, err := s.conn.write(write)
+	// 退避期间或写入超时时不再重试，避免每条日志多次等待
+	var netErr net.Error
+	if err != nil && !errors.Is(err, errSinkUnavailable) && !(errors.As(err, &netErr) && netErr.Timeout()) {
+		n, err = s.conn.write(write)
+	}
+	return n, err
+}
+
+func (s *syslogSink) dial() (net.Conn, error) {
+	if s.network == "udp" || s.network == "tcp" {
+		return net.DialTimeout(s.network, s.address, DefaultSinkDialTimeout)
+	}
+	addresses := syslogLocalAddresses
+	if s.network == "unix" {
+		addresses = []string{s.address}
+	}
+	var err error
+	for _, address := range addresses {
+		for _, network := range []string{"unixgram", "unix"} {
+			conn, dialErr := net.DialTimeout(network, address, DefaultSinkDialTimeout)
+			if dialErr == nil {
+				return conn, nil
+			}
+			err = dialErr
+		}
+	}
+	return nil, fmt.Errorf("connect to local syslog: %w", err)
+}
+
+// frame 按连接的网络类型分帧，tcp 使用 RFC 6587 的 octet-counting，unix stream 以换行分隔，数据报无需分帧
+func (s *syslogSink) frame(conn net.Conn, p []byte) []byte {
+	switch conn.RemoteAddr().Network() {
+	case "tcp":
+		return append([]byte(strconv.Itoa(len(p))+" "), p...)
+	case "unix":
+		return append(append([]byte(nil), p...), '\n')
+	default:
+		return p
+	}
+}
+
+func (s *syslogSink) Sync() error {
+	return nil
+}
+
+func (s *syslogSink) Close() error {
+	return s.conn.close()
+}
+
+// syslogSeverity 将 Level 映射为 syslog 严重级别，Trace 与 Debug 都映射为 debug
+func syslogSeverity(level zapcore.Level) int {
+	switch {
+	case level <= zapcore.DebugLevel:
+		return 7
+	case level == zapcore.InfoLevel:
+		return 6
+	case level == zapcore.WarnLevel:
+		return 4
+	case level == zapcore.ErrorLevel:
+		return 3
+	case level == zapcore.FatalLevel:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// syslogHeaderField 头部字段只能包含可见 ASCII 字符，为空时使用 NILVALUE
+func syslogHeaderField(value string, maxLen int) string {
+	value = strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 {
+			return '_'
+		}
+		return r
+	}, value)
+	if value == "" {
+		return "-"
+	}
+	if len(value) > maxLen {
+		value = value[:maxLen]
+	}
+	return value
+}
+
+// syslogStructuredData 将字段按名称排序写入一个 SD-ELEMENT，参数值中的 "、\ 和 ] 需要转义
+func syslogStructuredData(fields map[string]any) string {
+	if len(fields) == 0 {
+		return "-"
+	}
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf strings.Builder
+	buf.WriteString("[" + syslogSDID)
+	for _, name := range names {
+		value := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`).Replace(fmt.Sprint(fields[name]))
+		fmt.Fprintf(&buf, ` %s="%s"`, syslogParamName(name), value)
+	}
+	buf.WriteString("]")
+	return buf.String()
+}
+
+// syslogParamName PARAM-NAME 最长 32 个字符，不能包含 =、空格、] 和 "
+func syslogParamName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 || r == '=' || r == ']' || r == '"' {
+			return '_'
+		}
+		return r
+	}, name)
+	if name == "" {
+		return "_"
+	}
+	if len(name) > 32 {
+		name = name[:32]
+	}
+	return name
+}
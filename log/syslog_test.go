@@ -0,0 +1,184 @@
+package log
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestSyslog_RFC5424OverUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	logger, err := New().
+		WithFilename(filepath.Join(t.TempDir(), "app.log")).
+		WithoutStdout().
+		WithFields(map[string]any{"service": "api", "quote": `a"b]`}).
+		WithSyslog(SyslogOptions{Network: "udp", Address: conn.LocalAddr().String(), Facility: "local0", AppName: "api", Level: InfoLevel}).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer logger.Close()
+	logger.Named("http").Warn("over udp")
+
+	buf := make([]byte, 4096)
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// local0(16) * 8 + warning(4) = 132
+	pattern := `^<132>1 \d{4}-\d{2}-\d{2}T\S+ \S+ api ` + strconv.Itoa(os.Getpid()) +
+		` http \[noop@32473 quote="a\\"b\\]" service="api"\] \{.*"msg":"over udp".*\}$`
+	if !regexp.MustCompile(pattern).Match(buf[:n]) {
+		t.Errorf("message = %s", buf[:n])
+	}
+}
+
+func TestSyslog_RFC3164OverTCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	received := make(chan string, 4)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				reader := bufio.NewReader(conn)
+				for {
+					length, err := reader.ReadString(' ')
+					if err != nil {
+						return
+					}
+					n, _ := strconv.Atoi(strings.TrimSpace(length))
+					msg := make([]byte, n)
+					if _, err := reader.Read(msg); err != nil {
+						return
+					}
+					received <- string(msg)
+				}
+			}()
+		}
+	}()
+
+	u, _ := url.Parse("syslog+tcp://" + listener.Addr().String() + "?format=rfc3164&app=legacy")
+	sink, err := newSyslogSink(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := sink.(*syslogSink)
+	defer s.Close()
+	core := s.Core(zapcore.NewConsoleEncoder(consoleEncoderConfig()), zapcore.DebugLevel, nil)
+
+	write := func(msg string) {
+		t.Helper()
+		if err := core.Write(zapcore.Entry{Level: zapcore.ErrorLevel, Time: time.Now(), Message: msg}, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("first")
+	// 连接断开后重新连接
+	_ = s.conn.conn.Close()
+	write("second")
+
+	// 两条消息来自不同的连接，到达顺序不确定
+	got := make(map[string]bool)
+	for i := 0; i < 2; i++ {
+		select {
+		case msg := <-received:
+			// user(1) * 8 + err(3) = 11
+			match := regexp.MustCompile(`^<11>\w{3} [ \d]\d \d{2}:\d{2}:\d{2} \S+ legacy\[\d+\]: .*ERROR\t(\w+)$`).FindStringSubmatch(msg)
+			if match == nil {
+				t.Errorf("message = %q", msg)
+				continue
+			}
+			got[match[1]] = true
+		case <-time.After(5 * time.Second):
+			t.Fatal("messages were not received")
+		}
+	}
+	if !got["first"] || !got["second"] {
+		t.Errorf("received %v", got)
+	}
+}
+
+func TestSyslog_UnixDatagram(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Skipf("unixgram not supported: %v", err)
+	}
+	defer conn.Close()
+
+	logger := New().
+		WithFilename(filepath.Join(t.TempDir(), "app.log")).
+		WithoutStdout().
+		WithFields(map[string]any{"service": "api"}).
+		WithSink("syslog+unix://"+path+"?format=rfc3164&facility=daemon", DebugLevel, EncodingConsole).
+		Init()
+	defer logger.Close()
+	logger.Debug("over unix")
+
+	buf := make([]byte, 4096)
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// daemon(3) * 8 + debug(7) = 31，本地 socket 不带主机名，WithFields 的字段写入日志内容
+	if !regexp.MustCompile(`^<31>\w{3} [ \d]\d \d{2}:\d{2}:\d{2} \S+\[\d+\]: .*over unix\t\{"service": "api"\}$`).Match(buf[:n]) {
+		t.Errorf("message = %q", buf[:n])
+	}
+}
+
+func TestSyslog_Options(t *testing.T) {
+	for _, rawURL := range []string{"syslog+udp://", "syslog://?facility=nope", "syslog://?format=rfc9999"} {
+		u, _ := url.Parse(rawURL)
+		if _, err := newSyslogSink(u); err == nil {
+			t.Errorf("newSyslogSink(%s) should fail", rawURL)
+		}
+	}
+	if got := (SyslogOptions{Network: "unix", Address: "/dev/log", Facility: "local3"}).url(); got != "syslog+unix:///dev/log?facility=local3" {
+		t.Errorf("url = %s", got)
+	}
+	if got := syslogHeaderField("my app", 5); got != "my_ap" {
+		t.Errorf("header field = %s", got)
+	}
+}
+
+func TestSyslog_RedialBackoff(t *testing.T) {
+	u, _ := url.Parse("syslog+unix://" + filepath.Join(t.TempDir(), "missing"))
+	sink, err := newSyslogSink(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+
+	if _, err := sink.Write([]byte("first")); err == nil {
+		t.Fatal("Write() should fail without a syslog socket")
+	}
+	// 连接失败后在退避期间直接丢弃，不再逐条连接
+	if _, err := sink.Write([]byte("second")); !errors.Is(err, errSinkUnavailable) {
+		t.Errorf("Write() = %v, want errSinkUnavailable", err)
+	}
+}